@@ -0,0 +1,50 @@
+package handlers
+
+import (
+	"net/http"
+
+	"flight-dashboard-backend/services"
+
+	"github.com/labstack/echo/v4"
+)
+
+// returns every airline with its headline numbers, most flights first
+func GetAirlines(c echo.Context) error {
+	airlines := services.GetStateAggregator().GetAllAirlineAggregations()
+
+	airlineSummaries := make([]map[string]interface{}, 0, len(airlines))
+	for _, agg := range airlines {
+		airlineSummaries = append(airlineSummaries, map[string]interface{}{
+			"airline":       agg.Airline,
+			"slug":          agg.Slug,
+			"totalFlights":  agg.TotalFlights,
+			"nationalShare": agg.NationalShare,
+			"states":        len(agg.States),
+			"cities":        len(agg.Cities),
+			"routes":        len(agg.Routes),
+			"medianFare":    agg.Fares.Median,
+		})
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"success": true,
+		"data":    airlineSummaries,
+		"count":   len(airlineSummaries),
+	})
+}
+
+// returns one airline by name ("Air India") or slug ("air-india") with its states, cities, route network,
+// fares, class and stops mix and share of national traffic
+func GetAirline(c echo.Context) error {
+	airlineParam := c.Param("airline")
+	agg, exists := services.GetStateAggregator().GetAirlineAggregation(airlineParam)
+	if !exists {
+		return c.JSON(http.StatusNotFound, map[string]string{
+			"error": "Airline not found: " + airlineParam,
+		})
+	}
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"success": true,
+		"data":    agg,
+	})
+}
@@ -0,0 +1,41 @@
+package handlers
+
+import (
+	"net/http"
+
+	"flight-dashboard-backend/services"
+
+	"github.com/labstack/echo/v4"
+)
+
+// returns every airport in the registry, ?state= narrows it to one state
+func GetAirports(c echo.Context) error {
+	registry := services.GetAirportRegistry()
+
+	airports := registry.GetAllAirports()
+	if stateParam := c.QueryParam("state"); stateParam != "" {
+		airports = registry.GetAirportsInState(stateParam)
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"success": true,
+		"data":    airports,
+		"count":   len(airports),
+	})
+}
+
+// returns one airport by its IATA or ICAO code
+func GetAirport(c echo.Context) error {
+	code := c.Param("code")
+	airport, exists := services.GetAirportRegistry().GetAirport(code)
+	if !exists {
+		return c.JSON(http.StatusNotFound, map[string]string{
+			"error": "Airport not found: " + code,
+		})
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"success": true,
+		"data":    airport,
+	})
+}
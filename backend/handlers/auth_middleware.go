@@ -0,0 +1,62 @@
+package handlers
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/labstack/echo/v4"
+	"github.com/labstack/echo/v4/middleware"
+)
+
+// context key holding the name of the caller that passed the API key check
+const apiUserContextKey = "apiUser"
+
+// API keys allowed to call the write endpoints, keyed by the caller's name
+var apiKeys map[string]string
+var apiKeysOnce sync.Once
+
+// reads API keys from ADMIN_API_KEYS, formatted as "alice:key1,bob:key2"
+func loadAPIKeys() map[string]string {
+	apiKeysOnce.Do(func() {
+		apiKeys = make(map[string]string)
+		for _, entry := range strings.Split(os.Getenv("ADMIN_API_KEYS"), ",") {
+			name, key, found := strings.Cut(strings.TrimSpace(entry), ":")
+			if !found || name == "" || key == "" {
+				continue
+			}
+			apiKeys[strings.TrimSpace(name)] = strings.TrimSpace(key)
+		}
+	})
+	return apiKeys
+}
+
+// protects write endpoints - expects "Authorization: Bearer <key>" with a key from ADMIN_API_KEYS
+func RequireAPIKey() echo.MiddlewareFunc {
+	return middleware.KeyAuthWithConfig(middleware.KeyAuthConfig{
+		Validator: func(key string, c echo.Context) (bool, error) {
+			for name, validKey := range loadAPIKeys() {
+				if subtle.ConstantTimeCompare([]byte(key), []byte(validKey)) == 1 {
+					c.Set(apiUserContextKey, name)
+					return true, nil
+				}
+			}
+			return false, nil
+		},
+		ErrorHandler: func(err error, c echo.Context) error {
+			return c.JSON(http.StatusUnauthorized, map[string]string{
+				"error": "Missing or invalid API key",
+			})
+		},
+	})
+}
+
+// returns the name of the authenticated caller, empty when the request wasn't authenticated
+func apiUser(c echo.Context) string {
+	if name, ok := c.Get(apiUserContextKey).(string); ok {
+		return name
+	}
+	return ""
+}
@@ -0,0 +1,74 @@
+package handlers
+
+import (
+	"net/http"
+
+	"flight-dashboard-backend/services"
+
+	"github.com/labstack/echo/v4"
+)
+
+// returns every city with flights, most flights first - ?state= narrows it to the cities of one state
+// aliases and spellings of a city are merged, so Bangalore and Bengaluru are one entry
+func GetCities(c echo.Context) error {
+	cities := services.GetStateAggregator().GetAllCityAggregations()
+
+	stateName := ""
+	if stateParam := c.QueryParam("state"); stateParam != "" {
+		state, ok := services.GetStateRegistry().Resolve(stateParam)
+		if !ok {
+			return c.JSON(http.StatusNotFound, map[string]string{
+				"error": "State not found: " + stateParam,
+			})
+		}
+		stateName = state.Name
+	}
+
+	citySummaries := make([]map[string]interface{}, 0, len(cities))
+	for _, agg := range cities {
+		if stateName != "" && agg.State != stateName {
+			continue
+		}
+		citySummaries = append(citySummaries, map[string]interface{}{
+			"city":             agg.City,
+			"state":            agg.State,
+			"country":          agg.Country,
+			"totalFlights":     agg.TotalFlights,
+			"incomingFlights":  agg.IncomingFlights,
+			"outgoingFlights":  agg.OutgoingFlights,
+			"intraCityFlights": agg.IntraCityFlights,
+			"connectedCities":  len(agg.ConnectedCities),
+			"airlines":         len(agg.Airlines),
+		})
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"success":     true,
+		"data":        citySummaries,
+		"count":       len(citySummaries),
+		"definitions": cityDefinitions(),
+	})
+}
+
+// returns one city by name, alias, spelling or airport code with its connected cities, airlines and fares
+func GetCity(c echo.Context) error {
+	cityParam := c.Param("city")
+	agg, exists := services.GetStateAggregator().GetCityAggregation(cityParam)
+	if !exists {
+		return c.JSON(http.StatusNotFound, map[string]string{
+			"error": "City not found: " + cityParam,
+		})
+	}
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"success":     true,
+		"data":        agg,
+		"definitions": cityDefinitions(),
+	})
+}
+
+// what the city counts mean - cities have no transit count
+func cityDefinitions() map[string]string {
+	definitions := services.FlightCountDefinitions("city")
+	delete(definitions, "transitFlights")
+	return definitions
+}
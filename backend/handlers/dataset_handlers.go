@@ -0,0 +1,61 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"path/filepath"
+	"strings"
+
+	"flight-dashboard-backend/services"
+
+	"github.com/labstack/echo/v4"
+)
+
+// accepts a multipart CSV upload (field "file"), validates it and publishes it as the live dataset
+// responds with how many rows were accepted and rejected
+func UploadDataset(c echo.Context) error {
+	fileHeader, err := c.FormFile("file")
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Expected a CSV file in the 'file' form field",
+		})
+	}
+	if !strings.EqualFold(filepath.Ext(fileHeader.Filename), ".csv") {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Only .csv files are accepted: " + fileHeader.Filename,
+		})
+	}
+
+	file, err := fileHeader.Open()
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Could not read uploaded file: " + err.Error(),
+		})
+	}
+	defer file.Close()
+
+	report, err := services.GetDatasetReloader().Publish(file, fileHeader.Filename)
+	if errors.Is(err, services.ErrInvalidDataset) {
+		return c.JSON(http.StatusUnprocessableEntity, map[string]interface{}{
+			"error":  err.Error(),
+			"report": report,
+		})
+	}
+	if err != nil {
+		log.Printf("Dataset upload failed: %v", err)
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Could not publish dataset",
+		})
+	}
+
+	log.Printf("Dataset %s published by %s: %d accepted, %d rejected",
+		fileHeader.Filename, apiUser(c), report.AcceptedRows, report.RejectedRows)
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"success":      true,
+		"data":         report,
+		"acceptedRows": report.AcceptedRows,
+		"rejectedRows": report.RejectedRows,
+	})
+}
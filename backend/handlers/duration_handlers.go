@@ -0,0 +1,65 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"flight-dashboard-backend/services"
+
+	"github.com/labstack/echo/v4"
+)
+
+// returns the duration distribution in hours over every flight with a duration
+func GetDurations(c echo.Context) error {
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"success": true,
+		"data":    services.GetStateAggregator().GetOverallDurations(),
+	})
+}
+
+// returns the durations and efficiency of every state
+func GetStateDurations(c echo.Context) error {
+	states := services.GetStateAggregator().GetAllStateDurations()
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"success": true,
+		"data":    states,
+		"count":   len(states),
+	})
+}
+
+// returns the durations and efficiency of one state
+func GetStateDuration(c echo.Context) error {
+	stateParam := c.Param("state")
+	durations, exists := services.GetStateAggregator().GetDurationsForState(stateParam)
+	if !exists {
+		return c.JSON(http.StatusNotFound, map[string]string{
+			"error": "State not found: " + stateParam,
+		})
+	}
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"success": true,
+		"data":    durations,
+	})
+}
+
+// returns the durations, distance and efficiency of every city pair
+// ?sort=efficiency puts the routes that take longest compared to a direct flight first, the default is most flights first
+// ?source=, ?destination= and ?limit= narrow it down the same way as the route fares
+func GetRouteDurations(c echo.Context) error {
+	sortBy := c.QueryParam("sort")
+	if sortBy != services.RouteSortEfficiency {
+		sortBy = services.RouteSortFlights
+	}
+	routes := services.GetStateAggregator().GetRouteDurations(c.QueryParam("source"), c.QueryParam("destination"), sortBy)
+	total := len(routes)
+	if limit, err := strconv.Atoi(c.QueryParam("limit")); err == nil && limit > 0 && limit < len(routes) {
+		routes = routes[:limit]
+	}
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"success": true,
+		"data":    routes,
+		"count":   len(routes),
+		"total":   total,
+		"sort":    sortBy,
+	})
+}
@@ -0,0 +1,74 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"flight-dashboard-backend/services"
+
+	"github.com/labstack/echo/v4"
+)
+
+// returns the fare distribution over every flight with a price, with the distribution of each airline
+func GetFares(c echo.Context) error {
+	aggregator := services.GetStateAggregator()
+	airlines := aggregator.GetAirlineFares()
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"success":  true,
+		"data":     aggregator.GetOverallFares(),
+		"airlines": airlines,
+		"count":    len(airlines),
+	})
+}
+
+// returns the fares of every state as origin and as destination
+func GetStateFares(c echo.Context) error {
+	states := services.GetStateAggregator().GetAllStateFares()
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"success": true,
+		"data":    states,
+		"count":   len(states),
+	})
+}
+
+// returns the fares of one state as origin and as destination
+func GetStateFare(c echo.Context) error {
+	stateParam := c.Param("state")
+	fares, exists := services.GetStateAggregator().GetFaresForState(stateParam)
+	if !exists {
+		return c.JSON(http.StatusNotFound, map[string]string{
+			"error": "State not found: " + stateParam,
+		})
+	}
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"success": true,
+		"data":    fares,
+	})
+}
+
+// returns the fares of every city pair, most priced flights first
+// ?source= and ?destination= narrow it down (aliases like Bangalore work), ?limit= caps the list
+func GetRouteFares(c echo.Context) error {
+	routes := services.GetStateAggregator().GetRouteFares(c.QueryParam("source"), c.QueryParam("destination"))
+	total := len(routes)
+	if limit, err := strconv.Atoi(c.QueryParam("limit")); err == nil && limit > 0 && limit < len(routes) {
+		routes = routes[:limit]
+	}
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"success": true,
+		"data":    routes,
+		"count":   len(routes),
+		"total":   total,
+	})
+}
+
+// returns the fares of every airline
+func GetAirlineFares(c echo.Context) error {
+	airlines := services.GetStateAggregator().GetAirlineFares()
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"success": true,
+		"data":    airlines,
+		"count":   len(airlines),
+	})
+}
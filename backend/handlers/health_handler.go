@@ -1,14 +1,29 @@
 package handlers
 
 import (
+	"flight-dashboard-backend/services"
 	"net/http"
+
 	"github.com/labstack/echo/v4"
 )
 
 // health check to see if the server is running
+// also reports what's loaded so a bad or duplicate-heavy dataset is easy to spot
 func HealthHandler(c echo.Context) error {
-	return c.JSON(http.StatusOK, map[string]string{
+	dataService := services.GetFlightDataService()
+	report := dataService.GetIngestionReport()
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
 		"status":  "healthy",
 		"message": "Server is running fine .....",
+		"dataset": map[string]interface{}{
+			"path":               dataService.GetDataPath(),
+			"flights":            dataService.GetFlightCount(),
+			"rejected_rows":      report.RejectedRows,
+			"dedup_strategy":     report.DedupStrategy,
+			"duplicates":         report.Duplicates,
+			"duplicates_removed": report.DuplicatesRemoved,
+			"loaded_at":          report.LoadedAt,
+		},
 	})
-}
\ No newline at end of file
+}
@@ -0,0 +1,29 @@
+package handlers
+
+import (
+	"net/http"
+	"strings"
+
+	"flight-dashboard-backend/services"
+
+	"github.com/labstack/echo/v4"
+)
+
+// returns the ingestion report of the current dataset - dropped and coerced rows with line numbers
+// ?format=csv downloads just the issues as a CSV file
+func GetIngestionReport(c echo.Context) error {
+	report := services.GetFlightDataService().GetIngestionReport()
+
+	if strings.EqualFold(c.QueryParam("format"), "csv") {
+		c.Response().Header().Set(echo.HeaderContentDisposition, `attachment; filename="ingestion_report.csv"`)
+		c.Response().Header().Set(echo.HeaderContentType, "text/csv; charset=utf-8")
+		c.Response().WriteHeader(http.StatusOK)
+		return report.WriteIssuesCSV(c.Response())
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"success": true,
+		"data":    report,
+		"count":   len(report.Issues),
+	})
+}
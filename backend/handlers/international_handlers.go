@@ -0,0 +1,39 @@
+package handlers
+
+import (
+	"net/http"
+
+	"flight-dashboard-backend/services"
+
+	"github.com/labstack/echo/v4"
+)
+
+// returns the foreign destinations of each Indian state, ?state= narrows it to one state
+// flights counts every flight in the dataset by domestic/international classification
+func GetInternationalFlights(c echo.Context) error {
+	aggregator := services.GetStateAggregator()
+	states := aggregator.GetInternationalByState()
+
+	if stateParam := c.QueryParam("state"); stateParam != "" {
+		state, ok := services.GetStateRegistry().Resolve(stateParam)
+		if !ok {
+			return c.JSON(http.StatusNotFound, map[string]string{
+				"error": "State not found: " + stateParam,
+			})
+		}
+		filtered := []services.StateInternational{}
+		for _, entry := range states {
+			if entry.State == state.Name {
+				filtered = append(filtered, entry)
+			}
+		}
+		states = filtered
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"success": true,
+		"data":    states,
+		"count":   len(states),
+		"flights": aggregator.GetFlightScopes(),
+	})
+}
@@ -0,0 +1,127 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+
+	"flight-dashboard-backend/services"
+
+	"github.com/labstack/echo/v4"
+)
+
+// lists the dataset's city names that don't resolve to a state, with flight counts and suggested matches
+// also lists the names that only resolve through fuzzy matching so they can be turned into aliases
+func GetUnmappedCities(c echo.Context) error {
+	flights := services.GetFlightDataService().GetAllFlights()
+	report := services.GetCityStateMapper().BuildUnmappedReport(flights)
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"success": true,
+		"data":    report,
+		"count":   len(report.Unmapped),
+	})
+}
+
+// checks the city-state mapping for conflicts - duplicate cities, unknown states, dangling and missing aliases
+func GetMappingValidation(c echo.Context) error {
+	report := services.ValidateMapping()
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"success": true,
+		"data":    report,
+		"count":   len(report.Issues),
+	})
+}
+
+// body of the mapping edit endpoints - which fields are used depends on the endpoint
+type mappingEditRequest struct {
+	State string `json:"state"`
+	Name  string `json:"name"`
+	City  string `json:"city"`
+}
+
+// maps a city to a state, adding it if it's new - body: {"state": "karnataka"}
+func SetCityMapping(c echo.Context) error {
+	var req mappingEditRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid request body"})
+	}
+	change, err := services.GetCityStateMapper().SetCityState(c.Param("city"), req.State, apiUser(c))
+	return mappingChangeResponse(c, change, err)
+}
+
+// renames a city, aliases pointing at it follow along - body: {"name": "new name"}
+func RenameCityMapping(c echo.Context) error {
+	var req mappingEditRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid request body"})
+	}
+	change, err := services.GetCityStateMapper().RenameCity(c.Param("city"), req.Name, apiUser(c))
+	return mappingChangeResponse(c, change, err)
+}
+
+// removes a city and its aliases from the mapping
+func DeleteCityMapping(c echo.Context) error {
+	change, err := services.GetCityStateMapper().RemoveCity(c.Param("city"), apiUser(c))
+	return mappingChangeResponse(c, change, err)
+}
+
+// points an alias at a mapped city - body: {"city": "kochi"}
+func SetCityAlias(c echo.Context) error {
+	var req mappingEditRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid request body"})
+	}
+	change, err := services.GetCityStateMapper().SetAlias(c.Param("alias"), req.City, apiUser(c))
+	return mappingChangeResponse(c, change, err)
+}
+
+// removes an alias
+func DeleteCityAlias(c echo.Context) error {
+	change, err := services.GetCityStateMapper().RemoveAlias(c.Param("alias"), apiUser(c))
+	return mappingChangeResponse(c, change, err)
+}
+
+// returns the audit trail of mapping changes, newest first - ?limit= keeps just the latest ones
+func GetMappingAudit(c echo.Context) error {
+	limit := 0
+	if limitStr := c.QueryParam("limit"); limitStr != "" {
+		parsedLimit, err := strconv.Atoi(limitStr)
+		if err == nil && parsedLimit > 0 {
+			limit = parsedLimit
+		}
+	}
+
+	changes, err := services.GetCityStateMapper().GetMappingAudit(limit)
+	if err != nil {
+		log.Printf("Could not read mapping audit trail: %v", err)
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Could not read mapping audit trail",
+		})
+	}
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"success": true,
+		"data":    changes,
+		"count":   len(changes),
+	})
+}
+
+// turns the result of a mapping edit into a response - 400 for rejected edits, 404 for unknown entries
+func mappingChangeResponse(c echo.Context, change services.MappingChange, err error) error {
+	switch {
+	case errors.Is(err, services.ErrInvalidMapping):
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	case errors.Is(err, services.ErrMappingNotFound):
+		return c.JSON(http.StatusNotFound, map[string]string{"error": err.Error()})
+	case err != nil:
+		log.Printf("Mapping change failed: %v", err)
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Could not save mapping change",
+		})
+	}
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"success": true,
+		"data":    change,
+	})
+}
@@ -0,0 +1,24 @@
+package handlers
+
+import (
+	"net/http"
+
+	"flight-dashboard-backend/services"
+
+	"github.com/labstack/echo/v4"
+)
+
+// returns the state-to-state origin-destination matrix, ?airline= and ?class= narrow down the flights
+func GetODMatrix(c echo.Context) error {
+	filter := services.FlightFilter{
+		Airline: c.QueryParam("airline"),
+		Class:   c.QueryParam("class"),
+	}
+	matrix := services.GetStateAggregator().BuildODMatrix(filter)
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"success": true,
+		"data":    matrix,
+		"count":   len(matrix.Edges),
+	})
+}
@@ -0,0 +1,74 @@
+package handlers
+
+import (
+	"net/http"
+	"sort"
+
+	"flight-dashboard-backend/services"
+
+	"github.com/labstack/echo/v4"
+)
+
+// returns every region with its flight totals, plus the country-wide rollup
+func GetRegions(c echo.Context) error {
+	aggregator := services.GetStateAggregator()
+	regions := aggregator.GetAllRegionAggregations()
+
+	regionSummaries := make([]map[string]interface{}, 0, len(regions))
+	for _, agg := range regions {
+		regionSummaries = append(regionSummaries, regionResponse(agg))
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"success":     true,
+		"data":        regionSummaries,
+		"count":       len(regionSummaries),
+		"country":     regionResponse(aggregator.GetCountryAggregation()),
+		"definitions": services.FlightCountDefinitions("region"),
+	})
+}
+
+// returns one region by name or slug, with the same numbers as the state detail and a per-state breakdown
+func GetRegion(c echo.Context) error {
+	regionParam := c.Param("region")
+	agg, exists := services.GetStateAggregator().GetAggregationForRegion(regionParam)
+	if !exists {
+		return c.JSON(http.StatusNotFound, map[string]string{
+			"error": "Region not found: " + regionParam,
+		})
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"success":     true,
+		"data":        regionResponse(agg),
+		"definitions": services.FlightCountDefinitions("region"),
+	})
+}
+
+// formats a region the same way the state detail is formatted
+func regionResponse(agg *services.RegionAggregation) map[string]interface{} {
+	airlines := make([]string, 0, len(agg.Airlines))
+	for airline := range agg.Airlines {
+		airlines = append(airlines, airline)
+	}
+	sort.Strings(airlines)
+
+	response := map[string]interface{}{
+		"region":             agg.Region,
+		"slug":               agg.Slug,
+		"totalFlights":       agg.TotalFlights,
+		"incomingFlights":    agg.IncomingFlights,
+		"outgoingFlights":    agg.OutgoingFlights,
+		"intraRegionFlights": agg.IntraRegionFlights,
+		"transitFlights":     agg.TransitFlights,
+		"routes":             agg.UniqueRoutes,
+		"airlines":           airlines,
+	}
+	if agg.States != nil {
+		response["states"] = agg.States
+	}
+	if agg.Regions != nil {
+		response["regions"] = agg.Regions
+	}
+	return response
+}
@@ -0,0 +1,62 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"flight-dashboard-backend/services"
+
+	"github.com/labstack/echo/v4"
+)
+
+// page sizes of the flight lists
+const (
+	defaultPageSize = 50
+	maxPageSize     = 500
+)
+
+// returns everything about the flights from one city to another - airlines, fares by class, durations,
+// stops and departure hours, with the flights themselves paged by ?page= (from 1) and ?page_size=
+// either city can be an alias (Bangalore), a spelling the fuzzy matching knows or an airport code
+func GetRouteDetail(c echo.Context) error {
+	sourceParam := c.Param("source")
+	destinationParam := c.Param("destination")
+	detail, exists := services.GetStateAggregator().BuildRouteDetail(sourceParam, destinationParam)
+	if !exists {
+		return c.JSON(http.StatusNotFound, map[string]string{
+			"error": "Route not found: " + sourceParam + " to " + destinationParam,
+		})
+	}
+
+	page, pageSize := pagination(c, len(detail.Flights))
+	start := min((page-1)*pageSize, len(detail.Flights))
+	end := min(start+pageSize, len(detail.Flights))
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"success": true,
+		"data":    detail,
+		"flights": detail.Flights[start:end],
+		"count":   end - start,
+		"pagination": map[string]interface{}{
+			"page":         page,
+			"pageSize":     pageSize,
+			"totalFlights": len(detail.Flights),
+			"totalPages":   (len(detail.Flights) + pageSize - 1) / pageSize,
+		},
+	})
+}
+
+// reads ?page= and ?page_size=, falling back to the first page of defaultPageSize
+// pages past the end are clamped to the one right after the last, so they come back empty instead of overflowing
+func pagination(c echo.Context, total int) (int, int) {
+	page := 1
+	if parsedPage, err := strconv.Atoi(c.QueryParam("page")); err == nil && parsedPage > 0 {
+		page = parsedPage
+	}
+	pageSize := defaultPageSize
+	if parsedSize, err := strconv.Atoi(c.QueryParam("page_size")); err == nil && parsedSize > 0 {
+		pageSize = min(parsedSize, maxPageSize)
+	}
+	page = min(page, (total+pageSize-1)/pageSize+1)
+	return page, pageSize
+}
@@ -4,7 +4,6 @@ import (
 	"flight-dashboard-backend/services"
 	"net/http"
 	"strconv"
-	"strings"
 
 	"github.com/labstack/echo/v4"
 )
@@ -27,6 +26,7 @@ func GetStateWiseFlights(c echo.Context) error {
 		return c.JSON(http.StatusOK, map[string]interface{}{
 			"success": true,
 			"data":    agg,
+			"definitions": stateDefinitions(),
 		})
 	} else {
 		// gives all state aggregations
@@ -35,6 +35,7 @@ func GetStateWiseFlights(c echo.Context) error {
 			"success": true,
 			"data":    allAggs,
 			"count":   len(allAggs),
+			"definitions": stateDefinitions(),
 		})
 	}
 }
@@ -44,28 +45,25 @@ func GetStateList(c echo.Context) error {
 	aggregator := services.GetStateAggregator()
 	allAggs := aggregator.GetAllAggregations()
 
-	// getting all states
-	allIndianStates := aggregator.GetAllIndianStates()
+	// getting all states from the registry
+	allIndianStates := services.GetStateRegistry().GetAllStates()
 
 	// formatting response as array of state objects with total flights
 	stateSummaries := make([]map[string]interface{}, 0, len(allIndianStates))
-	for _, stateName := range allIndianStates {
-		// checking if a state is having flight data or not
-		normalizedStateName := strings.Title(strings.ToLower(stateName))
-		if agg, exists := allAggs[normalizedStateName]; exists {
-			stateSummary := map[string]interface{}{
-				"state":        stateName,
-				"totalFlights": agg.TotalFlights,
-			}
-			stateSummaries = append(stateSummaries, stateSummary)
-		} else {
-			// including states with 0 flights
-			stateSummary := map[string]interface{}{
-				"state":        stateName,
-				"totalFlights": 0,
-			}
-			stateSummaries = append(stateSummaries, stateSummary)
+	for _, state := range allIndianStates {
+		// including states with 0 flights
+		totalFlights := 0
+		if agg, exists := allAggs[state.Name]; exists {
+			totalFlights = agg.TotalFlights
 		}
+		stateSummaries = append(stateSummaries, map[string]interface{}{
+			"state":        state.Name,
+			"code":         state.Code,
+			"slug":         state.Slug,
+			"type":         state.Type,
+			"capital":      state.Capital,
+			"totalFlights": totalFlights,
+		})
 	}
 
 	return c.JSON(http.StatusOK, map[string]interface{}{
@@ -80,53 +78,45 @@ func GetStateList(c echo.Context) error {
 // returns data in the format: {"state": "Karnataka", "totalFlights": 2100, "incomingFlights": 980, "outgoingFlights": 1120, "routes": 120, "airlines": ["IndiGo", "Vistara", "Air India"]}
 func GetStateDetail(c echo.Context) error {
 	stateParam := c.Param("state")
-	normalizedState := normalizeStateName(stateParam)
 	aggregator := services.GetStateAggregator()
-	agg, exists := aggregator.GetAggregationForState(normalizedState)
-	// If not found with normalized name, try the original parameter
-	if !exists {
-		agg, exists = aggregator.GetAggregationForState(stateParam)
-	}
+	// name, slug ("tamil-nadu", "dnh-and-dd"), ISO code or old name all resolve through the state registry
+	agg, exists := aggregator.GetAggregationForState(stateParam)
 	if !exists {
 		return c.JSON(http.StatusNotFound, map[string]string{
 			"error": "State not found: " + stateParam,
 		})
 	}
 
-	// retriving airline names from the map
-	airlines := make([]string, 0, len(agg.Airlines))
-	for airline := range agg.Airlines {
-		airlines = append(airlines, airline)
-	}
+	// airline names, most flights first
+	airlines := agg.RankedAirlineNames()
 
 	// response format
+	state, _ := services.GetStateRegistry().Resolve(agg.StateName)
+	fares, _ := aggregator.GetFaresForState(agg.StateName)
+	durations, _ := aggregator.GetDurationsForState(agg.StateName)
 	response := map[string]interface{}{
 		"state":           agg.StateName,
+		"code":            state.Code,
+		"slug":            state.Slug,
 		"totalFlights":    agg.TotalFlights,
 		"incomingFlights": agg.IncomingFlights,
 		"outgoingFlights": agg.OutgoingFlights,
+		"intraStateFlights": agg.IntraStateFlights,
+		"transitFlights":  agg.TransitFlights,
 		"routes":          agg.UniqueRoutes,
 		"airlines":        airlines,
+		"internationalIncomingFlights": agg.InternationalIncoming,
+		"internationalOutgoingFlights": agg.InternationalOutgoing,
+		"fares":           fares, // same shape as /api/fares/states/:state
+		"durations":       durations, // same shape as /api/durations/states/:state
+		"definitions":     stateDefinitions(),
 	}
 
 	return c.JSON(http.StatusOK, response)
 }
 
-// converts kebab-case state names (like 'tamil-nadu') to proper format (like 'Tamil Nadu')
-func normalizeStateName(state string) string {
-	state = strings.ReplaceAll(state, "-", " ")
-	state = strings.Title(state)
-	words := strings.Split(state, " ")
-	for i, word := range words {
-		lowerWord := strings.ToLower(word)
-		if lowerWord == "and" || lowerWord == "or" {
-			words[i] = lowerWord
-		}
-	}
-	return strings.Join(words, " ")
-}
-
-// returns the top airlines for a specific state - used for the airline breakdown section
+// returns the airlines of a state ranked by flights, with market share, direction split and average fare
+// ?limit= (default 10) caps the list, the airlines past it are summed up in "others"
 func GetTopAirlinesForState(c echo.Context) error {
 	state := c.Param("state")
 	limitStr := c.QueryParam("limit")
@@ -140,9 +130,9 @@ func GetTopAirlinesForState(c echo.Context) error {
 	}
 
 	aggregator := services.GetStateAggregator()
-	airlines := aggregator.GetTopAirlinesForState(state, limit)
+	airlines, exists := aggregator.GetTopAirlinesForState(state, limit)
 
-	if airlines == nil {
+	if !exists {
 		return c.JSON(http.StatusNotFound, map[string]string{
 			"error": "State not found: " + state,
 		})
@@ -150,8 +140,65 @@ func GetTopAirlinesForState(c echo.Context) error {
 
 	return c.JSON(http.StatusOK, map[string]interface{}{
 		"success": true,
-		"state":   state,
-		"data":    airlines,
-		"count":   len(airlines),
+		"state":   airlines.State,
+		"data":    airlines.Airlines,
+		"others":  airlines.Others,
+		"count":   len(airlines.Airlines),
+		"totalFlights": airlines.TotalFlights,
+		"totalAirlines": airlines.AirlineCount,
 	})
 }
+
+// returns every district of a state with its flights - the map drills down to this when a state is clicked
+// district names match the "district" property of the state's topojson
+func GetStateDistricts(c echo.Context) error {
+	stateParam := c.Param("state")
+	aggregator := services.GetStateAggregator()
+	stateDistricts, exists := aggregator.GetDistrictAggregationsForState(stateParam)
+	if !exists {
+		return c.JSON(http.StatusNotFound, map[string]string{
+			"error": "State not found: " + stateParam,
+		})
+	}
+
+	districtSummaries := make([]map[string]interface{}, 0, len(stateDistricts.Districts))
+	for _, agg := range stateDistricts.Districts {
+		districtSummaries = append(districtSummaries, map[string]interface{}{
+			"district":        agg.District,
+			"totalFlights":    agg.TotalFlights,
+			"incomingFlights": agg.IncomingFlights,
+			"outgoingFlights": agg.OutgoingFlights,
+			"intraDistrictFlights": agg.IntraDistrictFlights,
+			"routes":          agg.UniqueRoutes,
+			"airlines":        len(agg.Airlines),
+		})
+	}
+
+	state, _ := services.GetStateRegistry().Resolve(stateDistricts.State)
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"success":           true,
+		"state":             state.Name,
+		"code":              state.Code,
+		"slug":              state.Slug,
+		"data":              districtSummaries,
+		"count":             len(districtSummaries),
+		"unassignedFlights": stateDistricts.UnassignedFlights, // flights of cities the district map doesn't place yet
+		"definitions":       districtDefinitions(),
+	})
+}
+
+// what the state counts mean, sent with every state response
+func stateDefinitions() map[string]string {
+	definitions := services.FlightCountDefinitions("state")
+	definitions["internationalIncomingFlights"] = "incoming flights from abroad, part of incomingFlights"
+	definitions["internationalOutgoingFlights"] = "outgoing flights to abroad, part of outgoingFlights"
+	return definitions
+}
+
+// what the district counts mean - districts have no transit count
+func districtDefinitions() map[string]string {
+	definitions := services.FlightCountDefinitions("district")
+	delete(definitions, "transitFlights")
+	return definitions
+}
@@ -4,21 +4,55 @@ import (
 	"flight-dashboard-backend/routes"
 	"flight-dashboard-backend/services"
 	"log"
+	"fmt"
+	"os"
+	"strings"
+	"time"
 
 	"github.com/labstack/echo/v4"
 	"github.com/labstack/echo/v4/middleware"
 )
 func main() {
+	// "server validate-mapping" checks the city-state mapping and exits - non-zero when it has conflicts
+	if len(os.Args) > 1 && os.Args[1] == "validate-mapping" {
+		os.Exit(validateMappingCommand())
+	}
+
 	services.GetCityStateMapper()
 	//log.Println("City-to-state mapping initialized")
 
-	// starting flight data service and load CSV data
+	// airport registry - lets datasets use IATA/ICAO codes instead of city names
+	services.GetAirportRegistry()
+
+	// region registry - groups states into the regions of /api/regions, read from data/regions.json
+	services.GetRegionRegistry()
+
+	// mapping validation - MAPPING_STRICT=true refuses to start on conflicts instead of just logging them
+	report := services.ValidateMapping()
+	for _, issue := range report.Issues {
+		if issue.Severity == services.MappingSeverityError {
+			log.Printf("Mapping %s (%s): %s", issue.Severity, issue.Source, issue.Message)
+		}
+	}
+	log.Printf("Mapping validation: %d errors, %d warnings (details at /api/mapping/validation)", report.Errors, report.Warnings)
+	if !report.Valid && strings.EqualFold(os.Getenv("MAPPING_STRICT"), "true") {
+		log.Fatalf("Refusing to start: city-state mapping has %d conflicts and MAPPING_STRICT is set", report.Errors)
+	}
+
+	// column schema - maps dataset headers to flight fields, every load is checked against it
+	services.GetColumnSchema()
+
+	// starting flight data service and load the dataset (csv, tsv, ndjson or parquet, optionally compressed)
+	// DATASET_PATH can point at a single file, a directory or a glob like "data/flights_*.csv"
 	dataService := services.GetFlightDataService()
-	csvPath := "data/dataset.csv"
-	err := dataService.LoadFlightDataFromCSV(csvPath)
+	datasetPath := os.Getenv("DATASET_PATH")
+	if datasetPath == "" {
+		datasetPath = "data/dataset.csv"
+	}
+	err := dataService.LoadFlightData(datasetPath)
 	if err != nil {
 		log.Printf("Warning: Could not load flight data: %v", err)
-		log.Println("Please ensure the CSV file exists in the data directory")
+		log.Println("Please ensure the dataset file exists in the data directory")
 	} else {
 		log.Printf("Successfully loaded %d flight records", dataService.GetFlightCount())
 	}
@@ -26,6 +60,12 @@ func main() {
 	// starting state aggregator (precomputes all state-wise aggregations)
 	services.GetStateAggregator()
 	//log.Println("State-wise aggregations computed and stored in memory")
+
+	// hot reload - picks up a replaced CSV (or a SIGHUP) without bouncing the process
+	reloader := services.GetDatasetReloader()
+	reloader.WatchPath(datasetPath, 30*time.Second)
+	reloader.WatchSignals()
+
 	e := echo.New()  //echo-fw
 	e.Use(middleware.Logger())  //middleware
 	e.Use(middleware.Recover())  //middleware
@@ -33,3 +73,16 @@ func main() {
 	routes.SetupRoutes(e)  //routes
 	e.Logger.Fatal(e.Start(":8080"))  //port-ini
 }
+
+// prints every mapping issue and returns the process exit code - 1 when there are errors
+func validateMappingCommand() int {
+	report := services.ValidateMapping()
+	for _, issue := range report.Issues {
+		fmt.Printf("%-7s %-15s %-28s %s\n", issue.Severity, issue.Kind, issue.Source, issue.Message)
+	}
+	fmt.Printf("\n%d errors, %d warnings\n", report.Errors, report.Warnings)
+	if !report.Valid {
+		return 1
+	}
+	return 0
+}
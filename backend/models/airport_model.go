@@ -0,0 +1,12 @@
+package models
+
+type Airport struct {
+	IATA      string  `json:"iata"`
+	ICAO      string  `json:"icao"`
+	Name      string  `json:"name"`
+	City      string  `json:"city"`
+	State     string  `json:"state"`   // empty for airports outside India
+	Country   string  `json:"country"` // "India" when the registry file doesn't say
+	Latitude  float64 `json:"latitude"`
+	Longitude float64 `json:"longitude"`
+}
@@ -1,4 +1,7 @@
 package models
+
+import "time"
+
 type Flight struct {
 	Airline        string  `json:"airline"`
 	FlightDate     string  `json:"flight_date"`
@@ -11,4 +14,20 @@ type Flight struct {
 	ArrivalTime    string  `json:"arrival_time"`
 	Stops          int     `json:"stops"`
 	AdditionalInfo string  `json:"additional_info"`
+	Route          string  `json:"route,omitempty"`     // airport codes as written in the dataset, e.g. "BLR → NAG → DEL"
+	SourceFile     string  `json:"source_file"`         // dataset file this flight was loaded from
+	Duplicate      bool    `json:"duplicate,omitempty"` // repeat of an earlier row, only set with the "flag" dedup strategy
+
+	// parsed from the raw strings above, all in IST - zero when the raw value couldn't be parsed
+	FlightDay time.Time   `json:"flight_day,omitzero"`
+	Departure time.Time   `json:"departure,omitzero"`
+	Arrival   time.Time   `json:"arrival,omitzero"`
+	BlockTime float64     `json:"block_time,omitempty"` // hours from departure to arrival
+	Legs      []FlightLeg `json:"legs,omitempty"`       // parsed from Route, in flying order
+}
+
+// one hop of a route between two airports, as IATA codes
+type FlightLeg struct {
+	From string `json:"from"`
+	To   string `json:"to"`
 }
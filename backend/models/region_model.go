@@ -0,0 +1,8 @@
+package models
+
+// a group of states the dashboard reports on as a whole, e.g. the Northeast
+type Region struct {
+	Name   string   `json:"name"`   // display name, e.g. "Northeast"
+	Slug   string   `json:"slug"`   // URL form, e.g. "northeast"
+	States []string `json:"states"` // canonical state names from the state registry
+}
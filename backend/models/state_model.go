@@ -0,0 +1,11 @@
+package models
+
+// a state or union territory of India as the state registry knows it
+type IndianState struct {
+	Name    string   `json:"name"` // canonical name, e.g. "Tamil Nadu"
+	Code    string   `json:"code"` // ISO 3166-2:IN, e.g. "IN-TN"
+	Slug    string   `json:"slug"` // URL form used by the frontend, e.g. "tamil-nadu"
+	Type    string   `json:"type"` // "state" or "union_territory"
+	Capital string   `json:"capital"`
+	Aliases []string `json:"aliases,omitempty"` // historic names and other spellings, e.g. "Orissa"
+}
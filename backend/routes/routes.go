@@ -4,6 +4,7 @@ import (
 	"flight-dashboard-backend/handlers"
 	"net/http"
 	"github.com/labstack/echo/v4"
+	"github.com/labstack/echo/v4/middleware"
 )
 
 func SetupRoutes(e *echo.Echo) {
@@ -18,5 +19,62 @@ func SetupRoutes(e *echo.Echo) {
 	e.GET("/api/state-flights", handlers.GetStateWiseFlights)
 	e.GET("/api/states", handlers.GetStateList)
 	e.GET("/api/state/:state", handlers.GetStateDetail)
+	e.GET("/api/state/:state/districts", handlers.GetStateDistricts)
 	e.GET("/api/states/:state/airlines", handlers.GetTopAirlinesForState)
+
+	// region endpoints - states rolled up into regions and the whole country
+	e.GET("/api/regions", handlers.GetRegions)
+	e.GET("/api/regions/:region", handlers.GetRegion)
+
+	// state-to-state origin-destination matrix (?airline=, ?class=) - feeds the chord diagram
+	e.GET("/api/od-matrix", handlers.GetODMatrix)
+
+	// city endpoints - aliases and spellings of a city are merged (Bangalore, Bengaluru)
+	e.GET("/api/cities", handlers.GetCities)
+	e.GET("/api/cities/:city", handlers.GetCity)
+
+	// airline endpoints - by name or slug (air-india)
+	e.GET("/api/airlines", handlers.GetAirlines)
+	e.GET("/api/airlines/:airline", handlers.GetAirline)
+
+	// one city pair with its airlines, fares, durations and flights (?page=, ?page_size=)
+	e.GET("/api/routes/:source/:destination", handlers.GetRouteDetail)
+
+	// fare statistics - min, max, mean, median, percentiles and standard deviation of the prices
+	e.GET("/api/fares", handlers.GetFares)
+	e.GET("/api/fares/states", handlers.GetStateFares)
+	e.GET("/api/fares/states/:state", handlers.GetStateFare)
+	e.GET("/api/fares/routes", handlers.GetRouteFares)
+	e.GET("/api/fares/airlines", handlers.GetAirlineFares)
+
+	// duration statistics in hours, with great-circle distance and efficiency against a direct flight
+	e.GET("/api/durations", handlers.GetDurations)
+	e.GET("/api/durations/states", handlers.GetStateDurations)
+	e.GET("/api/durations/states/:state", handlers.GetStateDuration)
+	e.GET("/api/durations/routes", handlers.GetRouteDurations)
+
+	// international flights - foreign destinations by Indian state of origin
+	e.GET("/api/international", handlers.GetInternationalFlights)
+
+	// airport registry endpoints - IATA or ICAO codes
+	e.GET("/api/airports", handlers.GetAirports)
+	e.GET("/api/airports/:code", handlers.GetAirport)
+
+	// city mapping endpoints - what the city-to-state mapping misses in the current dataset
+	e.GET("/api/mapping/unmapped", handlers.GetUnmappedCities)
+	e.GET("/api/mapping/validation", handlers.GetMappingValidation)
+
+	// ingestion report of the currently loaded dataset (?format=csv for a download)
+	e.GET("/api/ingestion/report", handlers.GetIngestionReport)
+
+	// dataset management endpoints - need an API key
+	e.POST("/api/datasets", handlers.UploadDataset, handlers.RequireAPIKey(), middleware.BodyLimit("100M"))
+
+	// city mapping admin endpoints - need an API key, every change is saved and audited
+	e.PUT("/api/admin/mapping/cities/:city", handlers.SetCityMapping, handlers.RequireAPIKey())
+	e.POST("/api/admin/mapping/cities/:city/rename", handlers.RenameCityMapping, handlers.RequireAPIKey())
+	e.DELETE("/api/admin/mapping/cities/:city", handlers.DeleteCityMapping, handlers.RequireAPIKey())
+	e.PUT("/api/admin/mapping/aliases/:alias", handlers.SetCityAlias, handlers.RequireAPIKey())
+	e.DELETE("/api/admin/mapping/aliases/:alias", handlers.DeleteCityAlias, handlers.RequireAPIKey())
+	e.GET("/api/admin/mapping/audit", handlers.GetMappingAudit, handlers.RequireAPIKey())
 }
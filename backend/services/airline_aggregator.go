@@ -0,0 +1,207 @@
+package services
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+
+	"flight-dashboard-backend/models"
+)
+
+// one state an airline serves - flights starting or ending in it, counted once each
+type AirlineState struct {
+	State   string `json:"state"`
+	Flights int    `json:"flights"`
+}
+
+// one city an airline serves, as the city mapping resolves it
+type AirlineCity struct {
+	City    string `json:"city"`
+	State   string `json:"state,omitempty"`   // empty for cities abroad and unmapped ones
+	Country string `json:"country,omitempty"` // empty for unmapped cities
+	Flights int    `json:"flights"`           // departures and arrivals
+}
+
+// one city pair an airline flies
+type AirlineRoute struct {
+	Source      string `json:"source"`
+	Destination string `json:"destination"`
+	Flights     int    `json:"flights"`
+}
+
+// everything one airline flies in the loaded flights
+type AirlineAggregation struct {
+	Airline         string            `json:"airline"`
+	Slug            string            `json:"slug"`
+	TotalFlights    int               `json:"total_flights"`
+	NationalFlights int               `json:"national_flights"` // flights with at least one end in India, the way the country total counts them
+	NationalShare   float64           `json:"national_share"`   // percent of the country's total flights
+	Scopes          map[string]int    `json:"scopes"`           // flights per domestic/international classification
+	States          []AirlineState    `json:"states"`           // most flights first
+	Cities          []AirlineCity     `json:"cities"`           // most flights first
+	Routes          []AirlineRoute    `json:"routes"`           // most flights first
+	Fares           DistributionStats `json:"fares"`
+	ClassMix        map[string]int    `json:"class_mix"` // flights per lower-case class, "unspecified" without a class column
+	StopsMix        map[int]int       `json:"stops_mix"` // flights per number of stops
+}
+
+var nonSlugChars = regexp.MustCompile(`[^a-z0-9]+`)
+
+// url-friendly key of an airline - "Air India" and "air-india" both become "air-india"
+func airlineSlug(name string) string {
+	return strings.Trim(nonSlugChars.ReplaceAllString(strings.ToLower(name), "-"), "-")
+}
+
+// builds the per-airline aggregations for the given flights without touching the stored ones
+// nationalTotal is the country's total flights, the base of each airline's national share
+func (sa *StateAggregator) buildAirlineAggregations(flights []models.Flight, nationalTotal int) map[string]*AirlineAggregation {
+	type builder struct {
+		agg    *AirlineAggregation
+		states map[string]int
+		cities map[string]int
+		routes map[[2]string]int
+		prices []float64
+	}
+	builders := make(map[string]*builder)
+
+	// raw names repeat on every row, so each one is resolved once
+	canonical := make(map[string]string)
+	resolve := func(city string) string {
+		if name, seen := canonical[city]; seen {
+			return name
+		}
+		canonical[city] = sa.mapper.canonicalCity(city)
+		return canonical[city]
+	}
+
+	for i := range flights {
+		flight := &flights[i]
+		slug := airlineSlug(flight.Airline)
+		build := builders[slug]
+		if build == nil {
+			build = &builder{
+				agg: &AirlineAggregation{
+					Airline:  strings.TrimSpace(flight.Airline),
+					Slug:     slug,
+					Scopes:   make(map[string]int),
+					ClassMix: make(map[string]int),
+					StopsMix: make(map[int]int),
+				},
+				states: make(map[string]int),
+				cities: make(map[string]int),
+				routes: make(map[[2]string]int),
+			}
+			builders[slug] = build
+		}
+
+		agg := build.agg
+		agg.TotalFlights++
+		agg.Scopes[sa.mapper.ClassifyFlight(flight)]++
+		agg.StopsMix[flight.Stops]++
+		class := strings.ToLower(strings.TrimSpace(flight.FlightClass))
+		if class == "" {
+			class = unspecifiedClass
+		}
+		agg.ClassMix[class]++
+		if flight.Price > 0 {
+			build.prices = append(build.prices, flight.Price)
+		}
+
+		sourceState, sourceOk, destState, destOk := sa.mapper.resolveFlightStates(flight)
+		if sourceOk || destOk {
+			agg.NationalFlights++
+		}
+		if sourceOk {
+			build.states[sourceState]++
+		}
+		if destOk && !(sourceOk && sourceState == destState) {
+			build.states[destState]++
+		}
+
+		source, dest := resolve(flight.Source), resolve(flight.Destination)
+		build.cities[source]++
+		if dest != source {
+			build.cities[dest]++
+		}
+		build.routes[[2]string{source, dest}]++
+	}
+
+	result := make(map[string]*AirlineAggregation, len(builders))
+	for slug, build := range builders {
+		agg := build.agg
+		if nationalTotal > 0 {
+			agg.NationalShare = roundTo(float64(agg.NationalFlights)*100/float64(nationalTotal), 2)
+		}
+		agg.Fares = distributionStats(build.prices)
+
+		agg.States = make([]AirlineState, 0, len(build.states))
+		for state, count := range build.states {
+			agg.States = append(agg.States, AirlineState{State: state, Flights: count})
+		}
+		sort.Slice(agg.States, func(i, j int) bool {
+			if agg.States[i].Flights != agg.States[j].Flights {
+				return agg.States[i].Flights > agg.States[j].Flights
+			}
+			return agg.States[i].State < agg.States[j].State
+		})
+
+		agg.Cities = make([]AirlineCity, 0, len(build.cities))
+		for city, count := range build.cities {
+			entry := AirlineCity{City: city, Flights: count}
+			if match, ok := sa.mapper.ResolveCity(city); ok {
+				entry.State = GetStateRegistry().CanonicalName(match.State)
+			}
+			entry.Country, _ = sa.mapper.GetCountryForCity(city)
+			agg.Cities = append(agg.Cities, entry)
+		}
+		sort.Slice(agg.Cities, func(i, j int) bool {
+			if agg.Cities[i].Flights != agg.Cities[j].Flights {
+				return agg.Cities[i].Flights > agg.Cities[j].Flights
+			}
+			return agg.Cities[i].City < agg.Cities[j].City
+		})
+
+		agg.Routes = make([]AirlineRoute, 0, len(build.routes))
+		for pair, count := range build.routes {
+			agg.Routes = append(agg.Routes, AirlineRoute{Source: pair[0], Destination: pair[1], Flights: count})
+		}
+		sort.Slice(agg.Routes, func(i, j int) bool {
+			a, b := agg.Routes[i], agg.Routes[j]
+			if a.Flights != b.Flights {
+				return a.Flights > b.Flights
+			}
+			if a.Source != b.Source {
+				return a.Source < b.Source
+			}
+			return a.Destination < b.Destination
+		})
+		result[slug] = agg
+	}
+	return result
+}
+
+// returns one airline by name or slug, case doesn't matter - the bool is false when it has no flights
+func (sa *StateAggregator) GetAirlineAggregation(name string) (*AirlineAggregation, bool) {
+	sa.mutex.RLock()
+	defer sa.mutex.RUnlock()
+	agg, exists := sa.airlines[airlineSlug(name)]
+	return agg, exists
+}
+
+// returns every airline, most flights first with ties ordered by name
+func (sa *StateAggregator) GetAllAirlineAggregations() []*AirlineAggregation {
+	sa.mutex.RLock()
+	defer sa.mutex.RUnlock()
+
+	result := make([]*AirlineAggregation, 0, len(sa.airlines))
+	for _, agg := range sa.airlines {
+		result = append(result, agg)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].TotalFlights != result[j].TotalFlights {
+			return result[i].TotalFlights > result[j].TotalFlights
+		}
+		return result[i].Slug < result[j].Slug
+	})
+	return result
+}
@@ -0,0 +1,161 @@
+package services
+
+import (
+	"sort"
+	"strings"
+
+	"flight-dashboard-backend/models"
+)
+
+// which side of a state a flight is counted on
+type flightDirection int
+
+const (
+	directionIncoming flightDirection = iota
+	directionOutgoing
+	directionIntra
+)
+
+// name of the bucket the airlines past the limit are rolled into
+const othersAirline = "Others"
+
+// one airline's flights in a state, split the same way as the state counts
+type airlineFlights struct {
+	incoming, outgoing, intra int
+	priceSum                  float64
+	pricedFlights             int
+}
+
+func (af *airlineFlights) total() int {
+	return af.incoming + af.outgoing + af.intra
+}
+
+func (af *airlineFlights) add(other *airlineFlights) {
+	af.incoming += other.incoming
+	af.outgoing += other.outgoing
+	af.intra += other.intra
+	af.priceSum += other.priceSum
+	af.pricedFlights += other.pricedFlights
+}
+
+// counts one flight of the state for its airline
+func (agg *StateAggregation) addAirlineFlight(flight *models.Flight, direction flightDirection) {
+	if agg.airlineFlights == nil {
+		agg.airlineFlights = make(map[string]*airlineFlights)
+	}
+	counts := agg.airlineFlights[flight.Airline]
+	if counts == nil {
+		counts = &airlineFlights{}
+		agg.airlineFlights[flight.Airline] = counts
+	}
+	switch direction {
+	case directionIncoming:
+		counts.incoming++
+	case directionOutgoing:
+		counts.outgoing++
+	default:
+		counts.intra++
+	}
+	if flight.Price > 0 {
+		counts.priceSum += flight.Price
+		counts.pricedFlights++
+	}
+}
+
+// one airline's place among the airlines of a state
+type RankedAirline struct {
+	Rank              int     `json:"rank"` // 1 for the most flights, 0 for the others bucket
+	Airline           string  `json:"airline"`
+	Flights           int     `json:"flights"`
+	MarketShare       float64 `json:"market_share"` // percent of the state's total flights
+	IncomingFlights   int     `json:"incoming_flights"`
+	OutgoingFlights   int     `json:"outgoing_flights"`
+	IntraStateFlights int     `json:"intra_state_flights"`
+	AverageFare       float64 `json:"average_fare"` // over the flights with a price, 0 without any
+}
+
+func newRankedAirline(rank int, name string, counts *airlineFlights, stateTotal int) RankedAirline {
+	airline := RankedAirline{
+		Rank:              rank,
+		Airline:           name,
+		Flights:           counts.total(),
+		IncomingFlights:   counts.incoming,
+		OutgoingFlights:   counts.outgoing,
+		IntraStateFlights: counts.intra,
+	}
+	if stateTotal > 0 {
+		airline.MarketShare = roundTo(float64(airline.Flights)*100/float64(stateTotal), 2)
+	}
+	if counts.pricedFlights > 0 {
+		airline.AverageFare = roundTo(counts.priceSum/float64(counts.pricedFlights), 2)
+	}
+	return airline
+}
+
+// the ranked airlines of one state
+type StateAirlines struct {
+	State        string          `json:"state"`
+	TotalFlights int             `json:"total_flights"`
+	AirlineCount int             `json:"airline_count"` // every airline, including the ones in others
+	Airlines     []RankedAirline `json:"airlines"`      // most flights first, at most the limit
+	Others       *RankedAirline  `json:"others"`        // the airlines past the limit rolled into one, nil when there are none
+}
+
+// ranks the airlines of a state by flights - ties go to the airline name, case-insensitive and then exact
+// only the first limit airlines are listed (all of them when limit <= 0), the rest are summed up in Others
+func (sa *StateAggregator) GetTopAirlinesForState(stateName string, limit int) (StateAirlines, bool) {
+	agg, exists := sa.GetAggregationForState(stateName)
+	if !exists {
+		return StateAirlines{}, false
+	}
+
+	names := make([]string, 0, len(agg.airlineFlights))
+	for name := range agg.airlineFlights {
+		names = append(names, name)
+	}
+	sortAirlines(names, func(name string) int { return agg.airlineFlights[name].total() })
+
+	result := StateAirlines{
+		State:        agg.StateName,
+		TotalFlights: agg.TotalFlights,
+		AirlineCount: len(names),
+		Airlines:     []RankedAirline{},
+	}
+	others := &airlineFlights{}
+	for i, name := range names {
+		if limit > 0 && i >= limit {
+			others.add(agg.airlineFlights[name])
+			continue
+		}
+		result.Airlines = append(result.Airlines, newRankedAirline(i+1, name, agg.airlineFlights[name], agg.TotalFlights))
+	}
+	if limit > 0 && len(names) > limit {
+		bucket := newRankedAirline(0, othersAirline, others, agg.TotalFlights)
+		result.Others = &bucket
+	}
+	return result, true
+}
+
+// returns a state's airline names ranked the same way as GetTopAirlinesForState
+func (agg *StateAggregation) RankedAirlineNames() []string {
+	names := make([]string, 0, len(agg.Airlines))
+	for name := range agg.Airlines {
+		names = append(names, name)
+	}
+	sortAirlines(names, func(name string) int { return agg.Airlines[name] })
+	return names
+}
+
+// sorts airline names by flights, most first - ties go to the name, case-insensitive and then exact
+func sortAirlines(names []string, flights func(string) int) {
+	sort.Slice(names, func(i, j int) bool {
+		a, b := flights(names[i]), flights(names[j])
+		if a != b {
+			return a > b
+		}
+		if lowerA, lowerB := strings.ToLower(names[i]), strings.ToLower(names[j]); lowerA != lowerB {
+			return lowerA < lowerB
+		}
+		return names[i] < names[j]
+	})
+}
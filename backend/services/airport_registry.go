@@ -0,0 +1,112 @@
+package services
+
+import (
+	"encoding/json"
+	"log"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+
+	"flight-dashboard-backend/models"
+)
+
+// looks airports up by IATA or ICAO code - loaded from data/airports.json
+type AirportRegistry struct {
+	airports []models.Airport          // sorted by IATA code
+	byCode   map[string]models.Airport // upper-case IATA and ICAO codes
+	byCity   map[string]models.Airport // lower-case city, the first airport by IATA code when a city has several
+}
+
+// global instance so the mapper, the aggregator and the handlers share one registry
+var airportRegistry *AirportRegistry
+var registryOnce sync.Once
+
+// returns singleton instance of the airport registry
+func GetAirportRegistry() *AirportRegistry {
+	registryOnce.Do(func() {
+		airportRegistry = loadAirportRegistry("data/airports.json")
+	})
+	return airportRegistry
+}
+
+// reads the registry file - without it codes just don't resolve, city names still work
+func loadAirportRegistry(path string) *AirportRegistry {
+	registry := &AirportRegistry{byCode: make(map[string]models.Airport), byCity: make(map[string]models.Airport)}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		log.Println("Could not load airport registry, airport codes won't be resolved:", err)
+		return registry
+	}
+	var airports []models.Airport
+	if err := json.Unmarshal(data, &airports); err != nil {
+		log.Printf("Error parsing airport registry JSON: %v, airport codes won't be resolved", err)
+		return registry
+	}
+
+	for _, airport := range airports {
+		airport.IATA = strings.ToUpper(strings.TrimSpace(airport.IATA))
+		airport.ICAO = strings.ToUpper(strings.TrimSpace(airport.ICAO))
+		if airport.Country == "" {
+			airport.Country = domesticCountry
+		}
+		if airport.IATA == "" {
+			log.Printf("Skipping airport without an IATA code: %s", airport.Name)
+			continue
+		}
+		if _, exists := registry.byCode[airport.IATA]; exists {
+			log.Printf("Duplicate airport code %s in registry, keeping the first one", airport.IATA)
+			continue
+		}
+		registry.airports = append(registry.airports, airport)
+		registry.byCode[airport.IATA] = airport
+		if airport.ICAO != "" {
+			registry.byCode[airport.ICAO] = airport
+		}
+	}
+	sort.Slice(registry.airports, func(i, j int) bool {
+		return registry.airports[i].IATA < registry.airports[j].IATA
+	})
+	for _, airport := range registry.airports {
+		city := strings.ToLower(strings.TrimSpace(airport.City))
+		if _, exists := registry.byCity[city]; !exists && city != "" {
+			registry.byCity[city] = airport
+		}
+	}
+
+	log.Printf("Loaded airport registry with %d airports", len(registry.airports))
+	return registry
+}
+
+// finds an airport by its IATA or ICAO code, case doesn't matter
+func (ar *AirportRegistry) GetAirport(code string) (models.Airport, bool) {
+	airport, exists := ar.byCode[strings.ToUpper(strings.TrimSpace(code))]
+	return airport, exists
+}
+
+// finds the airport of a city by the city name as written in the registry, case doesn't matter
+func (ar *AirportRegistry) GetAirportForCity(city string) (models.Airport, bool) {
+	airport, exists := ar.byCity[strings.ToLower(strings.TrimSpace(city))]
+	return airport, exists
+}
+
+// returns all airports sorted by IATA code
+func (ar *AirportRegistry) GetAllAirports() []models.Airport {
+	result := make([]models.Airport, len(ar.airports))
+	copy(result, ar.airports)
+	return result
+}
+
+// returns the airports in a state, sorted by IATA code - the state can be any name the state registry resolves
+func (ar *AirportRegistry) GetAirportsInState(state string) []models.Airport {
+	states := GetStateRegistry()
+	canonicalState := states.CanonicalName(state)
+	result := []models.Airport{}
+	for _, airport := range ar.airports {
+		if strings.EqualFold(states.CanonicalName(airport.State), canonicalState) {
+			result = append(result, airport)
+		}
+	}
+	return result
+}
@@ -0,0 +1,189 @@
+package services
+
+import (
+	"sort"
+	"strings"
+
+	"flight-dashboard-backend/models"
+)
+
+// flights between a city and one other city
+type CityConnection struct {
+	City     string `json:"city"`
+	Outgoing int    `json:"outgoing"` // to the connected city
+	Incoming int    `json:"incoming"` // from the connected city
+	Flights  int    `json:"flights"`  // both directions
+}
+
+// flights of one airline at a city
+type CityAirline struct {
+	Airline string `json:"airline"`
+	Flights int    `json:"flights"`
+}
+
+// flights of one city, counted once per flight the same way as for states - every spelling and alias
+// of the city (Bangalore, Banglore, BLR) is merged into the name the city mapping resolves it to
+type CityAggregation struct {
+	City               string            `json:"city"`
+	State              string            `json:"state,omitempty"`   // empty for cities abroad and unmapped ones
+	Country            string            `json:"country,omitempty"` // empty for unmapped cities
+	Names              []string          `json:"names"`             // the names the dataset uses for the city, sorted
+	TotalFlights       int               `json:"total_flights"`     // incoming + outgoing + intra-city
+	IncomingFlights    int               `json:"incoming_flights"`
+	OutgoingFlights    int               `json:"outgoing_flights"`
+	IntraCityFlights   int               `json:"intra_city_flights"` // both ends resolve to the city, usually two spellings of it
+	ConnectedCities    []CityConnection  `json:"connected_cities"`   // most flights first
+	Airlines           []CityAirline     `json:"airlines"`           // most flights first, ties by name
+	FaresAsOrigin      DistributionStats `json:"fares_as_origin"`
+	FaresAsDestination DistributionStats `json:"fares_as_destination"`
+}
+
+// builds the city aggregations for the given flights without touching the stored ones, keyed by resolved city
+func (sa *StateAggregator) buildCityAggregations(flights []models.Flight) map[string]*CityAggregation {
+	type builder struct {
+		agg         *CityAggregation
+		names       map[string]bool
+		connections map[string]*CityConnection
+		airlines    map[string]int
+		origin      []float64
+		destination []float64
+	}
+	builders := make(map[string]*builder)
+	get := func(city string) *builder {
+		build := builders[city]
+		if build == nil {
+			build = &builder{
+				agg:         &CityAggregation{City: city},
+				names:       make(map[string]bool),
+				connections: make(map[string]*CityConnection),
+				airlines:    make(map[string]int),
+			}
+			builders[city] = build
+		}
+		return build
+	}
+	connect := func(build *builder, city string) *CityConnection {
+		connection := build.connections[city]
+		if connection == nil {
+			connection = &CityConnection{City: city}
+			build.connections[city] = connection
+		}
+		return connection
+	}
+
+	// raw names repeat on every row, so each one is resolved once
+	canonical := make(map[string]string)
+	resolve := func(city string) string {
+		if name, seen := canonical[city]; seen {
+			return name
+		}
+		canonical[city] = sa.mapper.canonicalCity(city)
+		return canonical[city]
+	}
+
+	for i := range flights {
+		flight := &flights[i]
+		source, dest := resolve(flight.Source), resolve(flight.Destination)
+
+		origin := get(source)
+		origin.names[strings.TrimSpace(flight.Source)] = true
+		origin.agg.TotalFlights++
+		origin.airlines[flight.Airline]++
+		if flight.Price > 0 {
+			origin.origin = append(origin.origin, flight.Price)
+		}
+		if source == dest {
+			origin.names[strings.TrimSpace(flight.Destination)] = true
+			origin.agg.IntraCityFlights++
+			if flight.Price > 0 {
+				origin.destination = append(origin.destination, flight.Price)
+			}
+			continue
+		}
+		origin.agg.OutgoingFlights++
+		connection := connect(origin, dest)
+		connection.Outgoing++
+		connection.Flights++
+
+		arrival := get(dest)
+		arrival.names[strings.TrimSpace(flight.Destination)] = true
+		arrival.agg.TotalFlights++
+		arrival.agg.IncomingFlights++
+		arrival.airlines[flight.Airline]++
+		if flight.Price > 0 {
+			arrival.destination = append(arrival.destination, flight.Price)
+		}
+		connection = connect(arrival, source)
+		connection.Incoming++
+		connection.Flights++
+	}
+
+	result := make(map[string]*CityAggregation, len(builders))
+	for city, build := range builders {
+		agg := build.agg
+		if match, ok := sa.mapper.ResolveCity(city); ok {
+			agg.State = GetStateRegistry().CanonicalName(match.State)
+		}
+		agg.Country, _ = sa.mapper.GetCountryForCity(city)
+
+		agg.Names = make([]string, 0, len(build.names))
+		for name := range build.names {
+			agg.Names = append(agg.Names, name)
+		}
+		sort.Strings(agg.Names)
+
+		agg.ConnectedCities = make([]CityConnection, 0, len(build.connections))
+		for _, connection := range build.connections {
+			agg.ConnectedCities = append(agg.ConnectedCities, *connection)
+		}
+		sort.Slice(agg.ConnectedCities, func(i, j int) bool {
+			if agg.ConnectedCities[i].Flights != agg.ConnectedCities[j].Flights {
+				return agg.ConnectedCities[i].Flights > agg.ConnectedCities[j].Flights
+			}
+			return agg.ConnectedCities[i].City < agg.ConnectedCities[j].City
+		})
+
+		names := make([]string, 0, len(build.airlines))
+		for name := range build.airlines {
+			names = append(names, name)
+		}
+		sortAirlines(names, func(name string) int { return build.airlines[name] })
+		agg.Airlines = make([]CityAirline, 0, len(names))
+		for _, name := range names {
+			agg.Airlines = append(agg.Airlines, CityAirline{Airline: name, Flights: build.airlines[name]})
+		}
+
+		agg.FaresAsOrigin = distributionStats(build.origin)
+		agg.FaresAsDestination = distributionStats(build.destination)
+		result[city] = agg
+	}
+	return result
+}
+
+// returns one city by any name the city mapping resolves (Bangalore, Bengaluru, BLR) - false when it has no flights
+func (sa *StateAggregator) GetCityAggregation(name string) (*CityAggregation, bool) {
+	city := sa.mapper.canonicalCity(name)
+
+	sa.mutex.RLock()
+	defer sa.mutex.RUnlock()
+	agg, exists := sa.cities[city]
+	return agg, exists
+}
+
+// returns every city with flights, most flights first with ties ordered by name
+func (sa *StateAggregator) GetAllCityAggregations() []*CityAggregation {
+	sa.mutex.RLock()
+	defer sa.mutex.RUnlock()
+
+	result := make([]*CityAggregation, 0, len(sa.cities))
+	for _, agg := range sa.cities {
+		result = append(result, agg)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].TotalFlights != result[j].TotalFlights {
+			return result[i].TotalFlights > result[j].TotalFlights
+		}
+		return result[i].City < result[j].City
+	})
+	return result
+}
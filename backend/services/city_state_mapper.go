@@ -2,15 +2,54 @@ package services
 
 import (
 	"encoding/json"
+	"fmt"
 	"log"
 	"os"
+	"sort"
 	"strings"
 	"sync"
+
+	"flight-dashboard-backend/models"
 )
 
 // this mapper handles converting city names to state names needed for the flight data
+// the mapping can be edited while the server runs, so every read goes through the mutex
 type CityStateMapper struct {
 	cityToStateMap map[string]string
+	aliases        map[string]string         // alias -> city in cityToStateMap
+	matchThreshold float64                   // minimum confidence for a fuzzy match
+	fuzzyCache     map[string]CitySuggestion // fuzzy results per normalized name, empty City when nothing matched
+	fuzzyMutex     sync.Mutex
+	mutex          sync.RWMutex
+
+	// the district level below the states - loaded once, never edited at runtime
+	cityToDistrict   map[string]cityDistrict // city -> its district and the state that district is in
+	districtsByState map[string][]string     // canonical state -> its districts, sorted
+
+	// cities outside India - loaded once, never edited at runtime
+	cityToCountry map[string]string // foreign city -> country
+}
+
+// files the mapping is loaded from and saved back to
+const (
+	cityStateMapPath = "data/city_state_map.json"
+	cityAliasesPath  = "data/city_aliases.json"
+)
+
+// how a raw city name was resolved to a state
+const (
+	CityMatchExact   = "exact"
+	CityMatchAlias   = "alias"
+	CityMatchAirport = "airport"
+	CityMatchFuzzy   = "fuzzy"
+)
+
+// the known city a raw name resolved to
+type CityMatch struct {
+	City       string  `json:"city"`
+	State      string  `json:"state"`
+	Method     string  `json:"method"`
+	Confidence float64 `json:"confidence"` // 1 unless the match was fuzzy
 }
 
 // global instance so we can access the city-state mapping anywhere
@@ -20,8 +59,14 @@ var mapperOnce sync.Once
 // returns singleton instance of the city state mapper ensures only one instance exists
 func GetCityStateMapper() *CityStateMapper {
 	mapperOnce.Do(func() {
-		cityStateMapper = &CityStateMapper{}
+		cityStateMapper = &CityStateMapper{
+			matchThreshold: cityMatchThreshold(),
+			fuzzyCache:     make(map[string]CitySuggestion),
+		}
 		cityStateMapper.loadCityStateMap()
+		cityStateMapper.loadCityAliases()
+		cityStateMapper.loadDistrictMap()
+		cityStateMapper.loadCityCountryMap()
 	})
 	return cityStateMapper
 }
@@ -29,7 +74,7 @@ func GetCityStateMapper() *CityStateMapper {
 // loads the city to state mapping from JSON file or initializes it if file doesn't exist
 func (csm *CityStateMapper) loadCityStateMap() {
 	// try to load from JSON file first
-	data, err := os.ReadFile("data/city_state_map.json")
+	data, err := os.ReadFile(cityStateMapPath)
 	if err != nil {
 		log.Println("Could not load city-state map from JSON file, using default mapping:", err)
 		// default in use when not exists
@@ -42,12 +87,10 @@ func (csm *CityStateMapper) loadCityStateMap() {
 			csm.cityToStateMap = createDefaultCityStateMap()
 		} else {
 			// converting the raw map to a city-to-state mapping
-			csm.cityToStateMap = make(map[string]string)
-			for state, cities := range rawMap {
-				for _, city := range cities {
-					normalizedCity := strings.ToLower(strings.TrimSpace(city))
-					csm.cityToStateMap[normalizedCity] = strings.ToLower(state)
-				}
+			var conflicts []string
+			csm.cityToStateMap, conflicts = flattenCityStateLists(rawMap)
+			for _, conflict := range conflicts {
+				log.Printf("Warning: city-state map conflict: %s", conflict)
 			}
 		}
 	}
@@ -55,22 +98,102 @@ func (csm *CityStateMapper) loadCityStateMap() {
 	log.Printf("Loaded city-to-state mapping for %d cities", len(csm.cityToStateMap))
 }
 
+// loads city aliases from their own JSON file (alias -> city), the built-in list when it doesn't exist
+func (csm *CityStateMapper) loadCityAliases() {
+	csm.aliases = make(map[string]string, len(defaultCityAliases))
+	for alias, city := range defaultCityAliases {
+		csm.aliases[alias] = city
+	}
+
+	data, err := os.ReadFile(cityAliasesPath)
+	if err != nil {
+		log.Println("Could not load city aliases from JSON file, using default aliases:", err)
+		return
+	}
+	var rawAliases map[string]string
+	if err := json.Unmarshal(data, &rawAliases); err != nil {
+		log.Printf("Error parsing city aliases JSON: %v, using default aliases", err)
+		return
+	}
+
+	csm.aliases = make(map[string]string, len(rawAliases))
+	for alias, city := range rawAliases {
+		csm.aliases[strings.ToLower(strings.TrimSpace(alias))] = strings.ToLower(strings.TrimSpace(city))
+	}
+	log.Printf("Loaded %d city aliases", len(csm.aliases))
+}
+
 // returns the state for a given city used to map flight cities to states
 func (csm *CityStateMapper) GetStateForCity(city string) (string, bool) {
+	match, ok := csm.ResolveCity(city)
+	return match.State, ok
+}
+
+// resolves a raw city name - exact name first, then aliases, airport codes and finally fuzzy matching
+func (csm *CityStateMapper) ResolveCity(city string) (CityMatch, bool) {
 	if city == "" {
-		return "", false
+		return CityMatch{}, false
 	}
+	csm.mutex.RLock()
+	defer csm.mutex.RUnlock()
+
 	normalizedCity := normalizeCityName(city)
 	if state, exists := csm.cityToStateMap[normalizedCity]; exists {
-		return state, true
+		return CityMatch{City: normalizedCity, State: state, Method: CityMatchExact, Confidence: 1}, true
 	}
-	if alias := getCityAlias(normalizedCity); alias != "" {
+	if alias := csm.aliases[normalizedCity]; alias != "" {
 		if state, exists := csm.cityToStateMap[alias]; exists {
-			return state, true
+			return CityMatch{City: alias, State: state, Method: CityMatchAlias, Confidence: 1}, true
 		}
 	}
+	// datasets that use airport codes (BLR, VIDP) instead of city names
+	if airport, exists := GetAirportRegistry().GetAirport(city); exists && airport.State != "" {
+		return CityMatch{City: strings.ToLower(airport.City), State: strings.ToLower(airport.State), Method: CityMatchAirport, Confidence: 1}, true
+	}
+	// cities abroad (Dubai, Kathmandu) are never guessed to be an Indian city
+	if _, foreign := csm.lookupForeignCity(city); foreign {
+		return CityMatch{}, false
+	}
+	// typos and other spellings ("Banglore")
+	if suggestion, ok := csm.fuzzyMatch(normalizedCity); ok {
+		return CityMatch{City: suggestion.City, State: suggestion.State, Method: CityMatchFuzzy, Confidence: suggestion.Confidence}, true
+	}
 	//log.Printf("City not found in mapping: %s (normalized: %s)", city, normalizedCity)
-	return "", false
+	return CityMatch{}, false
+}
+
+// resolves the source and destination cities of a flight to canonical state names
+func (csm *CityStateMapper) resolveFlightStates(flight *models.Flight) (string, bool, string, bool) {
+	sourceState, sourceOk := csm.GetStateForCity(flight.Source)
+	destState, destOk := csm.GetStateForCity(flight.Destination)
+
+	// If not found with original name, try with normalized name
+	if !sourceOk {
+		sourceState, sourceOk = csm.GetStateForCity(normalizeCityNameForMapping(flight.Source))
+	}
+	if !destOk {
+		destState, destOk = csm.GetStateForCity(normalizeCityNameForMapping(flight.Destination))
+	}
+
+	if sourceOk {
+		sourceState = GetStateRegistry().CanonicalName(sourceState)
+	}
+	if destOk {
+		destState = GetStateRegistry().CanonicalName(destState)
+	}
+	return sourceState, sourceOk, destState, destOk
+}
+
+// the name a city is grouped under - the resolved city for known ones so aliases and spellings merge,
+// the foreign city for cities abroad and the normalized raw name for everything else
+func (csm *CityStateMapper) canonicalCity(city string) string {
+	if match, ok := csm.ResolveCity(city); ok {
+		return match.City
+	}
+	if foreign, ok := csm.lookupForeignCity(city); ok {
+		return foreign.City
+	}
+	return normalizeCityName(city)
 }
 
 // normalizeCityName normalizes city names for consistent lookup
@@ -91,239 +214,153 @@ func normalizeCityName(city string) string {
 	return normalized
 }
 
-// getCityAlias returns alternative names for cities that might be used
-func getCityAlias(city string) string {
-	// Common aliases for Indian cities
-	aliases := map[string]string{
-		"mumbai":      "mumbai",
-		"bombay":      "mumbai",
-		"delhi":       "delhi",
-		"new delhi":   "delhi",
-		"kolkata":     "kolkata",
-		"calcutta":    "kolkata",
-		"bengaluru":   "bengaluru",
-		"bangalore":   "bengaluru",
-		"madras":      "chennai",
-		"chennai":     "chennai",
-		"hyderabad":   "hyderabad",
-		"pondy":       "puducherry",
-		"ponducherry": "puducherry",
-		"puducherry":  "puducherry",
+// Common aliases for Indian cities - old names, short forms and other spellings
+// used when data/city_aliases.json doesn't exist
+var defaultCityAliases = map[string]string{
+	"mumbai":      "mumbai",
+	"bombay":      "mumbai",
+	"delhi":       "delhi",
+	"new delhi":   "delhi",
+	"kolkata":     "kolkata",
+	"calcutta":    "kolkata",
+	"bengaluru":   "bengaluru",
+	"bangalore":   "bengaluru",
+	"madras":      "chennai",
+	"chennai":     "chennai",
+	"hyderabad":   "hyderabad",
+	"pondy":       "puducherry",
+	"ponducherry": "puducherry",
+	"puducherry":  "puducherry",
+	"trivandrum":  "thiruvananthapuram",
+	"cochin":      "kochi",
+	"ernakulam":   "kochi",
+	"calicut":     "kozhikode",
+	"mangaluru":   "mangalore",
+	"mysuru":      "mysore",
+	"belagavi":    "belgaum",
+	"hubballi":    "hubli",
+	"kalaburagi":  "gulbarga",
+	"shivamogga":  "shimoga",
+	"trichy":      "tiruchirappalli",
+	"tuticorin":   "thoothukudi",
+	"vizag":       "visakhapatnam",
+	"baroda":      "vadodara",
+	"poona":       "pune",
+	"gurugram":    "gurgaon",
+	"prayagraj":   "allahabad",
+	"banaras":     "varanasi",
+	"benares":     "varanasi",
+}
+
+// turns state -> cities lists into a city -> state lookup
+// a city listed under more than one state goes to the first state alphabetically, so the result
+// doesn't depend on map order - the conflicts are returned so they can be reported
+func flattenCityStateLists(lists map[string][]string) (map[string]string, []string) {
+	states := make([]string, 0, len(lists))
+	for state := range lists {
+		states = append(states, state)
 	}
+	sort.Strings(states)
 
-	if alias, exists := aliases[city]; exists {
-		return alias
+	cityToState := make(map[string]string)
+	var conflicts []string
+	for _, state := range states {
+		normalizedState := strings.ToLower(strings.TrimSpace(state))
+		for _, city := range lists[state] {
+			normalizedCity := strings.ToLower(strings.TrimSpace(city))
+			if existing, exists := cityToState[normalizedCity]; exists {
+				if existing != normalizedState {
+					conflicts = append(conflicts, fmt.Sprintf("%q is under both %q and %q, using %q",
+						normalizedCity, existing, normalizedState, existing))
+				}
+				continue
+			}
+			cityToState[normalizedCity] = normalizedState
+		}
 	}
-	return ""
+	return cityToState, conflicts
 }
 
 // createDefaultCityStateMap creates a default mapping of major Indian cities to states
 func createDefaultCityStateMap() map[string]string {
-	cityToState := make(map[string]string)
-
-	// Andhra Pradesh
-	andhraCities := []string{"amaravati", "visakhapatnam", "vijayawada", "guntur", "nellore", "kurnool", "rajahmundry", "tirupati", "kakinada", "kadapa", "anantapur", "eluru", "ongole", "kadiri", "hindupur", "proddatur", "bhimavaram", "gudivada", "rajampet", "tadepalligudem", "srikakulam", "anakapalle", "nandyal", "suriapet", "adoni", "chittoor", "machilipatnam", "bapatla", "nagari", "narsapur", "tanuku", "yemmiganur", "sullurpeta", "palacole", "parvathipuram", "ramachandrapuram", "samalkot", "sattenapalle", "tadpatri", "tiruvuru", "venkatagiri"}
-	for _, city := range andhraCities {
-		cityToState[strings.ToLower(city)] = "andhra pradesh"
-	}
-
-	// Arunachal Pradesh
-	arunachalCities := []string{"itanagar", "naharlagun", "pasighat", "tawang", "bomdila", "tezu", "khonsa", "anini", "dambuk", "miao", "roing", "silapathar", "sagalee", "parang", "seppa", "bhalukpong", "changlang", "hawai", "jairampur", "koloriang", "lathao", "mohendraganj", "namsai", "pangin", "phassang", "ramsoh", "saiha", "sakoli", "sakrabaari", "tikabali", "zangla", "zirang", "ziro"}
-	for _, city := range arunachalCities {
-		cityToState[strings.ToLower(city)] = "arunachal pradesh"
-	}
-
-	// Assam
-	assamCities := []string{"dispur", "guwahati", "dibrugarh", "silchar", "tezpur", "jorhat", "nagaon", "tinsukia", "dhubri", "diphu", "north lakhimpur", "barpeta", "lakhimpur", "nalgonda", "sibsagar", "goalpara", "hailakandi", "dhemaji", "teok", "lumding", "mangaldoi", "marigaon", "narkatiaganj", "sadiya", "udalguri", "badarpur", "bilasipara", "kharupatia", "lanka", "morigaon", "razampur", "sorbhog", "tangla"}
-	for _, city := range assamCities {
-		cityToState[strings.ToLower(city)] = "assam"
-	}
-
-	// Bihar
-	biharCities := []string{"patna", "gaya", "bhagalpur", "muzaffarpur", "darbhanga", "begusarai", "chapra", "katihar", "munger", "purnia", "saharsa", "hajipur", "sasaram", "dehri", "aurangabad", "nawada", "jamalpur", "sitamarhi", "danapur", "madhubani", "siwan", "chhapra", "araria", "kishanganj", "madhepura", "arrah", "mokama", "sultanganj"}
-	for _, city := range biharCities {
-		cityToState[strings.ToLower(city)] = "bihar"
-	}
-
-	// Chhattisgarh
-	chhattisgarhCities := []string{"raipur", "bhilai", "korba", "bilaspur", "raigarh", "jagdalpur", "rajnandgaon", "ambikapur", "dhamtari", "chirmiri", "bhatapara", "sakti", "jashpur", "mahasamund", "dantewada", "bijapur", "narayanpur", "kanker", "kondagaon", "sukma", "balod", "baloda bazar", "bemetara", "gariaband", "gaurela pendra marwahi", "kabirdham"}
-	for _, city := range chhattisgarhCities {
-		cityToState[strings.ToLower(city)] = "chhattisgarh"
-	}
-
-	// Goa
-	goaCities := []string{"panaji", "margao", "mapusa", "mormugao", "bicholim", "ponda", "sanguem", "canacona", "pale", "quepem", "salcette", "cortalim", "cuncolim", "cunha", "goa velha", "jua", "kharebudr", "majorda", "mardol", "maria"}
-	for _, city := range goaCities {
-		cityToState[strings.ToLower(city)] = "goa"
-	}
-
-	// Gujarat
-	gujaratCities := []string{"gandhinagar", "ahmedabad", "surat", "vadodara", "rajkot", "bhavnagar", "jamnagar", "nadiad", "veraval", "gandhidham", "bharuch", "junagadh", "bhuj", "navsari", "botad", "dahod", "devbhoomi dwarka", "gir somnath", "kheda", "mehsana", "morbi", "narmada", "panchmahal", "patan", "surendranagar", "tapi", "vadodara", "valsad"}
-	for _, city := range gujaratCities {
-		cityToState[strings.ToLower(city)] = "gujarat"
-	}
-
-	// Haryana
-	haryanaCities := []string{"chandigarh", "faridabad", "gurgaon", "hisar", "rohtak", "panipat", "karnal", "sonipat", "yamunanagar", "bhiwani", "sirsa", "bahadurgarh", "jind", "thanesar", "kaithal", "palwal", "bawal", "charkhi dadri", "fatehabad", "gohana", "jagadhri", "kalka", "meham", "mewat", "narwana", "narnaul", "narnaund", "panchkula", "pundri", "radaur", "rajgarh", "safidon", "shahbad"}
-	for _, city := range haryanaCities {
-		cityToState[strings.ToLower(city)] = "haryana"
-	}
-
-	// Himachal Pradesh
-	himachalCities := []string{"shimla", "mandi", "solan", "nahan", "bilaspur", "kullu", "dharamshala", "palampur", "baddi", "nagrota", "una", "chamba", "pangi", "lahaul", "spiti", "kangra", "kinnaur", "hamirpur"}
-	for _, city := range himachalCities {
-		cityToState[strings.ToLower(city)] = "himachal pradesh"
-	}
-
-	// Jharkhand
-	jharkhandCities := []string{"ranchi", "jamshedpur", "dhanbad", "bokaro", "hazaribagh", "giridih", "deoghar", "chaibasa", "chatra", "dumka", "gumla", "pakur", "sahebganj", "simdega", "palamu", "latehar", "khunti", "littipara", "madhupur", "mihijam"}
-	for _, city := range jharkhandCities {
-		cityToState[strings.ToLower(city)] = "jharkhand"
-	}
-
-	// Karnataka
-	karnatakaCities := []string{"bengaluru", "mysore", "mangalore", "hubli", "davanagere", "belgaum", "gulbarga", "bellary", "bijapur", "shimoga", "tumkur", "mandya", "gadag", "raichur", "hassan", "dharmavaram", "chitradurga", "kolar", "udupi", "hospet", "bhatkal", "gokak", "madikeri", "ranibennur", "shahabad", "tarikere"}
-	for _, city := range karnatakaCities {
-		cityToState[strings.ToLower(city)] = "karnataka"
-	}
-
-	// Kerala
-	keralaCities := []string{"thiruvananthapuram", "kochi", "kollam", "kottayam", "palakkad", "alappuzha", "thrissur", "kannur", "kozhikode", "malappuram", "wayanad", "kasaragod", "pathanamthitta", "idukki"}
-	for _, city := range keralaCities {
-		cityToState[strings.ToLower(city)] = "kerala"
-	}
-
-	// Madhya Pradesh
-	madhyaCities := []string{"bhopal", "indore", "jabalpur", "gwalior", "ujjain", "sagar", "dewas", "satna", "rewa", "morena", "hoshangabad", "bhind", "damoh", "khargone", "mandsaur", "neemuch", "shahdol", "chhindwara", "guna", "tikamgarh", "sehore", "vijaypur", "ashoknagar", "shajapur", "seoni"}
-	for _, city := range madhyaCities {
-		cityToState[strings.ToLower(city)] = "madhya pradesh"
-	}
-
-	// Maharashtra
-	maharashtraCities := []string{"mumbai", "pune", "nagpur", "nashik", "aurangabad", "solapur", "thane", "jalgaon", "kolhapur", "amravati", "latur", "sangli", "nanded", "satara", "akola", "parbhani", "malegaon", "osmanabad", "nandurbar", "ahmednagar", "chandrapur", "dhule", "gondia", "hinganghat", "jalna", "khamgaon", "khopoli"}
-	for _, city := range maharashtraCities {
-		cityToState[strings.ToLower(city)] = "maharashtra"
-	}
-
-	// Manipur
-	manipurCities := []string{"imphal", "thoubal", "bishnupur", "churachandpur", "senapati", "tamenglong", "ukhrul", "kakching", "kangpokpi", "noney", "phungyar", "tengnoupal"}
-	for _, city := range manipurCities {
-		cityToState[strings.ToLower(city)] = "manipur"
-	}
-
-	// Meghalaya
-	meghalayaCities := []string{"shillong", "tura", "jowai", "nongstoin", "baghmara", "resubelpara", "williamnagar", "cherrapunji", "mairang", "mawkyrwat", "sohra", "nongpoh"}
-	for _, city := range meghalayaCities {
-		cityToState[strings.ToLower(city)] = "meghalaya"
-	}
-
-	// Mizoram
-	mizoramCities := []string{"aizawl", "lunglei", "champhai", "kolasib", "serchhip", "mamit", "saiha", "dampa", "hachhek", "tawi", "thenzawl"}
-	for _, city := range mizoramCities {
-		cityToState[strings.ToLower(city)] = "mizoram"
-	}
-
-	// Nagaland
-	nagalandCities := []string{"kohima", "dimapur", "mokokchung", "tuensang", "wokha", "zunheboto", "mon", "phek", "kiphire", "longleng"}
-	for _, city := range nagalandCities {
-		cityToState[strings.ToLower(city)] = "nagaland"
-	}
-
-	// Odisha
-	odishaCities := []string{"bhubaneswar", "cuttack", "rourkela", "sambalpur", "berhampur", "puri", "balasore", "bhadrak", "baripada", "kendrapara", "anugul", "bargarh", "baleshwar", "balangir", "boudh", "bhawanipatna", "bijapur", "bolangir", "dhenkanal", "jagatsinghpur", "jajpur", "jharsuguda", "kalahandi", "kapoorthala", "kendujhar", "koraput", "malkangiri", "mayurbhanj", "nabarangpur", "nayagarh", "nuapada", "phulbani", "rayagada", "subarnapur", "sundargarh", "tumudibandha"}
-	for _, city := range odishaCities {
-		cityToState[strings.ToLower(city)] = "odisha"
-	}
-
-	// Punjab
-	punjabCities := []string{"chandigarh", "ludhiana", "amritsar", "jalandhar", "patiala", "bathinda", "hoshiarpur", "moga", "mohali", "firozpur", "malerkotla", "mandi", "gobindgarh", "khanna", "fatehgarh sahib", "sangrur", "sunam", "dhuri", "zira", "fazilka", "kharar", "rajpura", "sirhind", "barnala", "jagraon", "kotkapura", "muktsar", "phagwara", "rampura", "tarn", "tarsikka", "dhariwal", "fatehgarh churian", "gurdaspur", "kapurthala", "rupnagar", "sas nagar", "sri muktsar sahib"}
-	for _, city := range punjabCities {
-		cityToState[strings.ToLower(city)] = "punjab"
-	}
-
-	// Rajasthan
-	rajasthanCities := []string{"jaipur", "jodhpur", "kota", "bikaner", "ajmer", "bhilwara", "alwar", "sikar", "sawai madhopur", "pali", "ganganagar", "bharatpur", "barmer", "tonk", "chittorgarh", "dungarpur", "sri ganganagar", "banswara", "dhaulpur", "dholpur", "karauli", "pratapgarh", "rajsamand", "udaipur", "hanumangarh", "jaisalmer", "jalore", "jhalawar", "jhunjhunu", "nagaur", "pratapgarh", "sirohi", "todalgarh"}
-	for _, city := range rajasthanCities {
-		cityToState[strings.ToLower(city)] = "rajasthan"
-	}
-
-	// Sikkim
-	sikkimCities := []string{"gangtok", "namchi", "gyalshing", "mangan", "soreng", "rajgung", "rhenock"}
-	for _, city := range sikkimCities {
-		cityToState[strings.ToLower(city)] = "sikkim"
-	}
-
-	// Tamil Nadu
-	tamilnaduCities := []string{"chennai", "coimbatore", "madurai", "tiruchirappalli", "salem", "tirunelveli", "tiruppur", "vellore", "thoothukudi", "erode", "tiruvannamalai", "pollachi", "rajapalayam", "ramanathapuram", "kanchipuram", "nagercoil", "dindigul", "karur", "nagapattinam", "kovilpatti", "karaikudi", "vaniyambadi", "sivakasi", "tiruchengode", "tirupattur", "ranipet", "tindivanam", "udumalaipettai", "virudhachalam", "virudhunagar"}
-	for _, city := range tamilnaduCities {
-		cityToState[strings.ToLower(city)] = "tamil nadu"
-	}
-
-	// Telangana
-	telanganaCities := []string{"hyderabad", "warangal", "nizamabad", "karimnagar", "ramagundam", "khammam", "mahbubnagar", "nalgonda", "suryapet", "miryalaguda", "siddipet", "adilabad", "sangareddy", "sircilla", "peddapalli", "bodhan", "mancherial", "kamareddy", "nirmal", "kotagiri"}
-	for _, city := range telanganaCities {
-		cityToState[strings.ToLower(city)] = "telangana"
-	}
-
-	// Tripura
-	tripuraCities := []string{"agartala", "udaipur", "dharmanagar", "pratapgarh", "kailasahar", "belonia", "ampinagar", "khowai", "phuldungri", "jagtial"}
-	for _, city := range tripuraCities {
-		cityToState[strings.ToLower(city)] = "tripura"
-	}
-
-	// Uttar Pradesh
-	upCities := []string{"lucknow", "kanpur", "agra", "varanasi", "meerut", "allahabad", "gorakhpur", "noida", "ghaziabad", "bareilly", "aligarh", "saharanpur", "mathura", "firozabad", "muzaffarnagar", "moradabad", "gwalior", "bhopal", "indore", "jabalpur", "raipur", "bilaspur", "durg", "raigarh", "jagdalpur", "rajnandgaon", "ambikapur", "dhamtari", "chirmiri", "bhatapara"}
-	for _, city := range upCities {
-		cityToState[strings.ToLower(city)] = "uttar pradesh"
-	}
-
-	// Uttarakhand
-	uttarakhandCities := []string{"dehradun", "haridwar", "rishikesh", "udupi", "haldwani", "kathgodam", "kashipur", "rudrapur", "khatima", "sitarganj", "jaspur", "pauri", "chakrata", "chamoli", "dakpathar", "devprayag", "dhandhera", "dharasu", "dhumak", "dwarahat", "gairsain", "gangaikhera", "gangotri", "gauchar", "gaurikund", "guptkashi", "hardwar", "harsil", "jawalmukhi", "jhandi", "joshimath", "kalsi", "kanalich", "kanda", "karnaprayag", "kashipur", "khirshn", "kotdwar", "laksar", "lalkuan", "lansdowne", "lohardaga", "manali", "mandi", "manglaur", "mukteshwar", "nagla", "nainital", "nandaprayag", "narendranagar", "paddal", "padampur", "pauri", "phata", "pilkha", "pithoragarh", "pratapnagar", "prayag", "purola", "raipur", "raithal", "ranikhet", "roorkee", "rudraprayag", "uttarkashi", "vanspurnagar", "vijaypur", "vikasnagar", "virbhadra", "yamkeshwar", "yamunotri"}
-	for _, city := range uttarakhandCities {
-		cityToState[strings.ToLower(city)] = "uttarakhand"
-	}
-
-	// West Bengal
-	wbCities := []string{"kolkata", "siliguri", "durgapur", "asansol", "malda", "raiganj", "kharagpur", "jalpaiguri", "cooch behar", "bankura", "darjeeling", "krishnanagar", "berhampore", "bally", "budge budge", "dhulian", "dankuni", "haldia", "kulti", "kamarhati", "medinipur", "nabadwip", "purulia", "shantipur", "suri", "tamluk", "alipurduar"}
-	for _, city := range wbCities {
-		cityToState[strings.ToLower(city)] = "west bengal"
-	}
-
-	// Delhi
-	delhiCities := []string{"new delhi", "delhi", "north delhi", "south delhi", "east delhi", "west delhi", "central delhi", "north west delhi", "south west delhi", "north east delhi", "shahdara", "new delhi", "palam", "rohini", "pitampura", "karol bagh", "connaught place", "defence colony", "greater kailash", "hauz khas", "karkardooma", "lajpat nagar", "mayur vihar", "narela", "noida", "pandav nagar", "paschim vihar", "rajouri garden", "saket", "vasant kunj", "vishwas nagar", "yamuna vihar"}
-	for _, city := range delhiCities {
-		cityToState[strings.ToLower(city)] = "delhi"
-	}
-
-	// Puducherry
-	puducherryCities := []string{"puducherry", "karaikal", "mahe", "yanaon", "pondicherry", "oussudu", "kannigapuram", "thattanchavady", "mannadipet", "mudaliarpet"}
-	for _, city := range puducherryCities {
-		cityToState[strings.ToLower(city)] = "puducherry"
-	}
-
-	// Andaman and Nicobar Islands
-	andamanCities := []string{"port blair", "car nicobar", "coco island", "havelock island", "interview island", "jerry point", "katchal", "landfall island", "little andaman", "mahiya", "nancowry", "north and middle andaman", "north and south tillanchong", "north sentinel island", "phoenix bay", "ross island", "saddle peak", "south andaman", "swaraj dweep", "tillanchong", "viper island"}
-	for _, city := range andamanCities {
-		cityToState[strings.ToLower(city)] = "andaman and nicobar islands"
-	}
-
-	// Dadra and Nagar Haveli and Daman and Diu
-	dnhDiuCities := []string{"daman", "dadar", "nagar haveli", "silvassa", "dnh", "dnh and dd", "dnh dd", "dnh diu"}
-	for _, city := range dnhDiuCities {
-		cityToState[strings.ToLower(city)] = "dadra and nagar haveli and daman and diu"
-	}
-
-	// Lakshadweep
-	lakshadweepCities := []string{"kavaratti", "agatti", "andrott", "bitra", "chethlath", "kadmath", "kalpeni", "kiltan", "minicoy", "muhassar", "pandarani", "thinnakara"}
-	for _, city := range lakshadweepCities {
-		cityToState[strings.ToLower(city)] = "lakshadweep"
-	}
+	cityToState, _ := flattenCityStateLists(defaultCityStateLists())
+	return cityToState
+}
 
-	// Ladakh
-	ladakhCities := []string{"leh", "kargil", "drass", "padum", "zanskar", "nubra", "pangong", "tso", "tso kar", "tso moriri", "changthang", "nyoma", "urud"}
-	for _, city := range ladakhCities {
-		cityToState[strings.ToLower(city)] = "ladakh"
+// the built-in state -> cities lists behind createDefaultCityStateMap, kept as lists so they can be validated
+func defaultCityStateLists() map[string][]string {
+	return map[string][]string{
+		// Andhra Pradesh
+		"andhra pradesh": {"amaravati", "visakhapatnam", "vijayawada", "guntur", "nellore", "kurnool", "rajahmundry", "tirupati", "kakinada", "kadapa", "anantapur", "eluru", "ongole", "kadiri", "hindupur", "proddatur", "bhimavaram", "gudivada", "rajampet", "tadepalligudem", "srikakulam", "anakapalle", "nandyal", "suriapet", "adoni", "chittoor", "machilipatnam", "bapatla", "nagari", "narsapur", "tanuku", "yemmiganur", "sullurpeta", "palacole", "parvathipuram", "ramachandrapuram", "samalkot", "sattenapalle", "tadpatri", "tiruvuru", "venkatagiri"},
+		// Arunachal Pradesh
+		"arunachal pradesh": {"itanagar", "naharlagun", "pasighat", "tawang", "bomdila", "tezu", "khonsa", "anini", "dambuk", "miao", "roing", "silapathar", "sagalee", "parang", "seppa", "bhalukpong", "changlang", "hawai", "jairampur", "koloriang", "lathao", "mohendraganj", "namsai", "pangin", "phassang", "ramsoh", "sakoli", "sakrabaari", "tikabali", "zangla", "zirang", "ziro"},
+		// Assam
+		"assam": {"dispur", "guwahati", "dibrugarh", "silchar", "tezpur", "jorhat", "nagaon", "tinsukia", "dhubri", "diphu", "north lakhimpur", "barpeta", "lakhimpur", "sibsagar", "goalpara", "hailakandi", "dhemaji", "teok", "lumding", "mangaldoi", "marigaon", "narkatiaganj", "sadiya", "udalguri", "badarpur", "bilasipara", "kharupatia", "lanka", "morigaon", "razampur", "sorbhog", "tangla"},
+		// Bihar
+		"bihar": {"patna", "gaya", "bhagalpur", "muzaffarpur", "darbhanga", "begusarai", "chapra", "katihar", "munger", "purnia", "saharsa", "hajipur", "sasaram", "dehri", "nawada", "jamalpur", "sitamarhi", "danapur", "madhubani", "siwan", "chhapra", "araria", "kishanganj", "madhepura", "arrah", "mokama", "sultanganj"},
+		// Chhattisgarh
+		"chhattisgarh": {"raipur", "bhilai", "korba", "bilaspur", "raigarh", "jagdalpur", "rajnandgaon", "ambikapur", "dhamtari", "chirmiri", "bhatapara", "sakti", "jashpur", "mahasamund", "dantewada", "narayanpur", "kanker", "kondagaon", "sukma", "balod", "baloda bazar", "bemetara", "gariaband", "gaurela pendra marwahi", "kabirdham", "durg"},
+		// Goa
+		"goa": {"panaji", "margao", "mapusa", "mormugao", "bicholim", "ponda", "sanguem", "canacona", "pale", "quepem", "salcette", "cortalim", "cuncolim", "cunha", "goa velha", "jua", "kharebudr", "majorda", "mardol", "maria", "vasco da gama", "mopa"},
+		// Gujarat
+		"gujarat": {"gandhinagar", "ahmedabad", "surat", "vadodara", "rajkot", "bhavnagar", "jamnagar", "nadiad", "veraval", "gandhidham", "bharuch", "junagadh", "bhuj", "navsari", "botad", "dahod", "devbhoomi dwarka", "gir somnath", "kheda", "mehsana", "morbi", "narmada", "panchmahal", "patan", "surendranagar", "tapi", "valsad"},
+		// Haryana
+		"haryana": {"faridabad", "gurgaon", "hisar", "rohtak", "panipat", "karnal", "sonipat", "yamunanagar", "bhiwani", "sirsa", "bahadurgarh", "jind", "thanesar", "kaithal", "palwal", "bawal", "charkhi dadri", "fatehabad", "gohana", "jagadhri", "kalka", "meham", "mewat", "narwana", "narnaul", "narnaund", "panchkula", "pundri", "radaur", "rajgarh", "safidon", "shahbad"},
+		// Himachal Pradesh
+		"himachal pradesh": {"shimla", "mandi", "solan", "nahan", "kullu", "dharamshala", "palampur", "baddi", "nagrota", "una", "chamba", "pangi", "lahaul", "spiti", "kangra", "kinnaur", "hamirpur"},
+		// Jharkhand
+		"jharkhand": {"ranchi", "jamshedpur", "dhanbad", "bokaro", "hazaribagh", "giridih", "deoghar", "chaibasa", "chatra", "dumka", "gumla", "pakur", "sahebganj", "simdega", "palamu", "latehar", "khunti", "littipara", "madhupur", "mihijam", "lohardaga"},
+		// Karnataka
+		"karnataka": {"bengaluru", "mysore", "mangalore", "hubli", "davanagere", "belgaum", "gulbarga", "bellary", "bijapur", "shimoga", "tumkur", "mandya", "gadag", "raichur", "hassan", "dharmavaram", "chitradurga", "kolar", "udupi", "hospet", "bhatkal", "gokak", "madikeri", "ranibennur", "shahabad", "tarikere"},
+		// Kerala
+		"kerala": {"thiruvananthapuram", "kochi", "kollam", "kottayam", "palakkad", "alappuzha", "thrissur", "kannur", "kozhikode", "malappuram", "wayanad", "kasaragod", "pathanamthitta", "idukki"},
+		// Madhya Pradesh
+		"madhya pradesh": {"bhopal", "indore", "jabalpur", "gwalior", "ujjain", "sagar", "dewas", "satna", "rewa", "morena", "hoshangabad", "bhind", "damoh", "khargone", "mandsaur", "neemuch", "shahdol", "chhindwara", "guna", "tikamgarh", "sehore", "vijaypur", "ashoknagar", "shajapur", "seoni"},
+		// Maharashtra
+		"maharashtra": {"mumbai", "pune", "nagpur", "nashik", "aurangabad", "solapur", "thane", "jalgaon", "kolhapur", "amravati", "latur", "sangli", "nanded", "satara", "akola", "parbhani", "malegaon", "osmanabad", "nandurbar", "ahmednagar", "chandrapur", "dhule", "gondia", "hinganghat", "jalna", "khamgaon", "khopoli"},
+		// Manipur
+		"manipur": {"imphal", "thoubal", "bishnupur", "churachandpur", "senapati", "tamenglong", "ukhrul", "kakching", "kangpokpi", "noney", "phungyar", "tengnoupal"},
+		// Meghalaya
+		"meghalaya": {"shillong", "tura", "jowai", "nongstoin", "baghmara", "resubelpara", "williamnagar", "cherrapunji", "mairang", "mawkyrwat", "sohra", "nongpoh"},
+		// Mizoram
+		"mizoram": {"aizawl", "lunglei", "champhai", "kolasib", "serchhip", "mamit", "saiha", "dampa", "hachhek", "tawi", "thenzawl"},
+		// Nagaland
+		"nagaland": {"kohima", "dimapur", "mokokchung", "tuensang", "wokha", "zunheboto", "mon", "phek", "kiphire", "longleng"},
+		// Odisha
+		"odisha": {"bhubaneswar", "cuttack", "rourkela", "sambalpur", "berhampur", "puri", "balasore", "bhadrak", "baripada", "kendrapara", "anugul", "bargarh", "baleshwar", "balangir", "boudh", "bhawanipatna", "bolangir", "dhenkanal", "jagatsinghpur", "jajpur", "jharsuguda", "kalahandi", "kapoorthala", "kendujhar", "koraput", "malkangiri", "mayurbhanj", "nabarangpur", "nayagarh", "nuapada", "phulbani", "rayagada", "subarnapur", "sundargarh", "tumudibandha"},
+		// Punjab
+		"punjab": {"ludhiana", "amritsar", "jalandhar", "patiala", "bathinda", "hoshiarpur", "moga", "mohali", "firozpur", "malerkotla", "gobindgarh", "khanna", "fatehgarh sahib", "sangrur", "sunam", "dhuri", "zira", "fazilka", "kharar", "rajpura", "sirhind", "barnala", "jagraon", "kotkapura", "muktsar", "phagwara", "rampura", "tarn", "tarsikka", "dhariwal", "fatehgarh churian", "gurdaspur", "kapurthala", "rupnagar", "sas nagar", "sri muktsar sahib"},
+		// Rajasthan
+		"rajasthan": {"jaipur", "jodhpur", "kota", "bikaner", "ajmer", "bhilwara", "alwar", "sikar", "sawai madhopur", "pali", "ganganagar", "bharatpur", "barmer", "tonk", "chittorgarh", "dungarpur", "sri ganganagar", "banswara", "dhaulpur", "dholpur", "karauli", "pratapgarh", "rajsamand", "udaipur", "hanumangarh", "jaisalmer", "jalore", "jhalawar", "jhunjhunu", "nagaur", "sirohi", "todalgarh"},
+		// Sikkim
+		"sikkim": {"gangtok", "namchi", "gyalshing", "mangan", "soreng", "rajgung", "rhenock"},
+		// Tamil Nadu
+		"tamil nadu": {"chennai", "coimbatore", "madurai", "tiruchirappalli", "salem", "tirunelveli", "tiruppur", "vellore", "thoothukudi", "erode", "tiruvannamalai", "pollachi", "rajapalayam", "ramanathapuram", "kanchipuram", "nagercoil", "dindigul", "karur", "nagapattinam", "kovilpatti", "karaikudi", "vaniyambadi", "sivakasi", "tiruchengode", "tirupattur", "ranipet", "tindivanam", "udumalaipettai", "virudhachalam", "virudhunagar"},
+		// Telangana
+		"telangana": {"hyderabad", "warangal", "nizamabad", "karimnagar", "ramagundam", "khammam", "mahbubnagar", "nalgonda", "suryapet", "miryalaguda", "siddipet", "adilabad", "sangareddy", "sircilla", "peddapalli", "bodhan", "mancherial", "kamareddy", "nirmal", "kotagiri"},
+		// Tripura
+		"tripura": {"agartala", "dharmanagar", "kailasahar", "belonia", "ampinagar", "khowai", "phuldungri", "jagtial"},
+		// Uttar Pradesh
+		"uttar pradesh": {"lucknow", "kanpur", "agra", "varanasi", "meerut", "allahabad", "gorakhpur", "noida", "ghaziabad", "bareilly", "aligarh", "saharanpur", "mathura", "firozabad", "muzaffarnagar", "moradabad"},
+		// Uttarakhand
+		"uttarakhand": {"dehradun", "haridwar", "rishikesh", "haldwani", "kathgodam", "kashipur", "rudrapur", "khatima", "sitarganj", "jaspur", "pauri", "chakrata", "chamoli", "dakpathar", "devprayag", "dhandhera", "dharasu", "dhumak", "dwarahat", "gairsain", "gangaikhera", "gangotri", "gauchar", "gaurikund", "guptkashi", "hardwar", "harsil", "jawalmukhi", "jhandi", "joshimath", "kalsi", "kanalich", "kanda", "karnaprayag", "khirshn", "kotdwar", "laksar", "lalkuan", "lansdowne", "manali", "manglaur", "mukteshwar", "nagla", "nainital", "nandaprayag", "narendranagar", "paddal", "padampur", "phata", "pilkha", "pithoragarh", "pratapnagar", "prayag", "purola", "raithal", "ranikhet", "roorkee", "rudraprayag", "uttarkashi", "vanspurnagar", "vikasnagar", "virbhadra", "yamkeshwar", "yamunotri", "pantnagar"},
+		// West Bengal
+		"west bengal": {"kolkata", "siliguri", "durgapur", "asansol", "malda", "raiganj", "kharagpur", "jalpaiguri", "cooch behar", "bankura", "darjeeling", "krishnanagar", "berhampore", "bally", "budge budge", "dhulian", "dankuni", "haldia", "kulti", "kamarhati", "medinipur", "nabadwip", "purulia", "shantipur", "suri", "tamluk", "alipurduar"},
+		// Delhi
+		"delhi": {"new delhi", "delhi", "north delhi", "south delhi", "east delhi", "west delhi", "central delhi", "north west delhi", "south west delhi", "north east delhi", "shahdara", "palam", "rohini", "pitampura", "karol bagh", "connaught place", "defence colony", "greater kailash", "hauz khas", "karkardooma", "lajpat nagar", "mayur vihar", "narela", "pandav nagar", "paschim vihar", "rajouri garden", "saket", "vasant kunj", "vishwas nagar", "yamuna vihar"},
+		// Puducherry
+		"puducherry": {"puducherry", "karaikal", "mahe", "yanaon", "pondicherry", "oussudu", "kannigapuram", "thattanchavady", "mannadipet", "mudaliarpet"},
+		// Andaman and Nicobar Islands
+		"andaman and nicobar islands": {"port blair", "car nicobar", "coco island", "havelock island", "interview island", "jerry point", "katchal", "landfall island", "little andaman", "mahiya", "nancowry", "north and middle andaman", "north and south tillanchong", "north sentinel island", "phoenix bay", "ross island", "saddle peak", "south andaman", "swaraj dweep", "tillanchong", "viper island"},
+		// Dadra and Nagar Haveli and Daman and Diu
+		"dadra and nagar haveli and daman and diu": {"daman", "dadar", "nagar haveli", "silvassa", "dnh", "dnh and dd", "dnh dd", "dnh diu", "diu"},
+		// Lakshadweep
+		"lakshadweep": {"kavaratti", "agatti", "andrott", "bitra", "chethlath", "kadmath", "kalpeni", "kiltan", "minicoy", "muhassar", "pandarani", "thinnakara"},
+		// Ladakh
+		"ladakh": {"leh", "kargil", "drass", "padum", "zanskar", "nubra", "pangong", "tso", "tso kar", "tso moriri", "changthang", "nyoma", "urud"},
+		// Jammu and Kashmir
+		"jammu and kashmir": {"srinagar", "jammu", "anantnag", "baramulla", "sopore", "kathua", "udhampur", "pulwama", "kupwara", "rajouri", "poonch", "doda", "kishtwar", "ganderbal", "budgam", "bandipora", "shopian", "kulgam", "ramban", "reasi", "samba", "gulmarg", "pahalgam", "katra"},
+		// Chandigarh
+		"chandigarh": {"chandigarh"},
 	}
-
-	return cityToState
 }
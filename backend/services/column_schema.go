@@ -0,0 +1,391 @@
+package services
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"flight-dashboard-backend/models"
+)
+
+// logical models.Flight fields a dataset column can be mapped to, in the order they're parsed
+var schemaFields = []string{
+	"airline", "flight_date", "source", "destination", "flight_class", "duration",
+	"price", "departure_time", "arrival_time", "stops", "additional_info", "route",
+}
+
+// parsers a field can use - "text" just trims the value
+var schemaParsers = map[string]bool{"text": true, "date": true, "duration": true, "price": true, "stops": true}
+
+// how one logical field is found in a file header and turned into a value
+type FieldSpec struct {
+	Headers  []string `json:"headers"`           // header names to try, first match wins (case-insensitive)
+	Required bool     `json:"required"`          // header must exist and the value can't be empty
+	Parser   string   `json:"parser,omitempty"`  // text (default), date, duration, price or stops
+	Layouts  []string `json:"layouts,omitempty"` // date: Go time layouts to try, e.g. "2/1/2006"
+	Format   string   `json:"format,omitempty"`  // duration: auto (default), hours, minutes or hh:mm
+	Locale   string   `json:"locale,omitempty"`  // price: en-IN (default, 1,234.50) or eu (1.234,50)
+}
+
+// maps logical flight fields to dataset columns - loaded from data/column_schema.json
+type ColumnSchema struct {
+	Fields map[string]FieldSpec `json:"fields"`
+	Dedup  *DedupConfig         `json:"dedup,omitempty"` // defaults to defaultDedupConfig when left out
+}
+
+// the schema resolved against one file header - field name to column index
+type headerBinding struct {
+	schema  *ColumnSchema
+	indices map[string]int
+}
+
+// global instance so every loader uses the same mapping
+var columnSchema *ColumnSchema
+var schemaOnce sync.Once
+
+// returns singleton instance of the column schema
+func GetColumnSchema() *ColumnSchema {
+	schemaOnce.Do(func() {
+		columnSchema = loadColumnSchema("data/column_schema.json")
+	})
+	return columnSchema
+}
+
+// loads the schema file, falling back to the built-in one when it's missing or invalid
+func loadColumnSchema(path string) *ColumnSchema {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		log.Println("Could not load column schema from JSON file, using default schema:", err)
+		return defaultColumnSchema()
+	}
+
+	var schema ColumnSchema
+	if err := json.Unmarshal(data, &schema); err != nil {
+		log.Printf("Error parsing column schema JSON: %v, using default schema", err)
+		return defaultColumnSchema()
+	}
+	if schema.Dedup == nil {
+		dedup := defaultDedupConfig()
+		schema.Dedup = &dedup
+	}
+	if err := schema.validate(); err != nil {
+		log.Printf("Invalid column schema in %s: %v, using default schema", path, err)
+		return defaultColumnSchema()
+	}
+
+	log.Printf("Loaded column schema for %d fields from %s", len(schema.Fields), path)
+	return &schema
+}
+
+// tells if a name is one of the logical flight fields
+func isSchemaField(name string) bool {
+	for _, field := range schemaFields {
+		if field == name {
+			return true
+		}
+	}
+	return false
+}
+
+// checks the schema only uses known fields, parsers and options
+func (cs *ColumnSchema) validate() error {
+	for field, spec := range cs.Fields {
+		if !isSchemaField(field) {
+			return fmt.Errorf("unknown field %q", field)
+		}
+		if len(spec.Headers) == 0 {
+			return fmt.Errorf("field %q has no headers", field)
+		}
+		parser := spec.parser()
+		if !schemaParsers[parser] {
+			return fmt.Errorf("field %q uses unknown parser %q", field, spec.Parser)
+		}
+		if parser == "date" && len(spec.Layouts) == 0 {
+			return fmt.Errorf("field %q needs at least one date layout", field)
+		}
+		switch spec.Format {
+		case "", "auto", "hours", "minutes", "hh:mm":
+		default:
+			return fmt.Errorf("field %q uses unknown duration format %q", field, spec.Format)
+		}
+		switch spec.Locale {
+		case "", "en-IN", "eu":
+		default:
+			return fmt.Errorf("field %q uses unknown price locale %q", field, spec.Locale)
+		}
+	}
+
+	for _, field := range []string{"source", "destination"} {
+		if _, exists := cs.Fields[field]; !exists {
+			return fmt.Errorf("field %q must be mapped", field)
+		}
+	}
+	return cs.Dedup.validate()
+}
+
+// returns the parser name, defaulting to text
+func (fs FieldSpec) parser() string {
+	if fs.Parser == "" {
+		return "text"
+	}
+	return fs.Parser
+}
+
+// resolves the schema against a file header - fails when a required field has no column
+func (cs *ColumnSchema) bindHeader(header []string) (*headerBinding, error) {
+	columns := make(map[string]int, len(header))
+	for i, col := range header {
+		// the first column wins if a header name repeats
+		name := strings.TrimSpace(strings.ToLower(strings.TrimPrefix(col, "\ufeff")))
+		if _, exists := columns[name]; !exists {
+			columns[name] = i
+		}
+	}
+
+	binding := &headerBinding{schema: cs, indices: make(map[string]int)}
+	used := make(map[int]bool)
+	for _, field := range schemaFields {
+		spec, exists := cs.Fields[field]
+		if !exists {
+			continue
+		}
+		for _, name := range spec.Headers {
+			if idx, found := columns[strings.ToLower(name)]; found {
+				binding.indices[field] = idx
+				used[idx] = true
+				break
+			}
+		}
+		if _, bound := binding.indices[field]; !bound && spec.Required {
+			return nil, fmt.Errorf("%w: missing %s column (expected one of %s)",
+				ErrInvalidDataset, field, strings.Join(spec.Headers, ", "))
+		}
+	}
+
+	var unused []string
+	for i, col := range header {
+		if !used[i] {
+			unused = append(unused, col)
+		}
+	}
+	if len(unused) > 0 {
+		log.Printf("Columns not mapped by the column schema, ignoring: %s", strings.Join(unused, ", "))
+	}
+	return binding, nil
+}
+
+// returns the trimmed value of a field in a record, empty if the field isn't in the file
+func (hb *headerBinding) value(record []string, field string) string {
+	if idx, exists := hb.indices[field]; exists && idx < len(record) {
+		return strings.TrimSpace(record[idx])
+	}
+	return ""
+}
+
+// converts a record to a Flight struct using the schema
+// returns every problem found on the way - an error means the row has to be dropped
+func parseFlightRecord(record []string, binding *headerBinding, line int) (models.Flight, []IngestionIssue, error) {
+	var flight models.Flight
+	var issues []IngestionIssue
+
+	for _, field := range schemaFields {
+		spec, exists := binding.schema.Fields[field]
+		if !exists {
+			continue
+		}
+		raw := binding.value(record, field)
+		if raw == "" {
+			if spec.Required {
+				issues = append(issues, IngestionIssue{Line: line, Column: field, Action: IssueDropped, Reason: "missing " + field})
+				return flight, issues, fmt.Errorf("missing %s", field)
+			}
+			continue
+		}
+
+		value, err := spec.parse(raw)
+		if err != nil {
+			issues = append(issues, IngestionIssue{Line: line, Column: field, RawValue: raw, Action: IssueCoerced, Reason: err.Error()})
+		}
+		setFlightField(&flight, field, value)
+	}
+
+	issues = append(issues, resolveFlightTimestamps(&flight, line)...)
+	issues = append(issues, resolveFlightRoute(&flight, binding.value(record, "stops"), line)...)
+	return flight, issues, nil
+}
+
+// runs the field's parser - on error the returned value is the default to store instead
+func (fs FieldSpec) parse(raw string) (interface{}, error) {
+	switch fs.parser() {
+	case "date":
+		for _, layout := range fs.Layouts {
+			if t, err := time.Parse(layout, raw); err == nil {
+				return t.Format("2006-01-02"), nil
+			}
+		}
+		return raw, fmt.Errorf("doesn't match date layouts %s, kept as is", strings.Join(fs.Layouts, ", "))
+	case "duration":
+		hours, ok := parseDurationFormat(raw, fs.Format)
+		if !ok {
+			return 0.0, fmt.Errorf("unrecognised duration, using 0")
+		}
+		return hours, nil
+	case "price":
+		price, ok := parsePriceLocale(raw, fs.Locale)
+		if !ok {
+			return 0.0, fmt.Errorf("not a number, using 0")
+		}
+		return price, nil
+	case "stops":
+		stops, ok := parseStops(raw)
+		if !ok {
+			return 0, fmt.Errorf("not a number, using 0")
+		}
+		return stops, nil
+	}
+	return raw, nil
+}
+
+// stores a parsed value on the matching Flight field
+func setFlightField(flight *models.Flight, field string, value interface{}) {
+	switch v := value.(type) {
+	case string:
+		switch field {
+		case "airline":
+			flight.Airline = v
+		case "flight_date":
+			flight.FlightDate = v
+		case "source":
+			flight.Source = v
+		case "destination":
+			flight.Destination = v
+		case "flight_class":
+			flight.FlightClass = v
+		case "departure_time":
+			flight.DepartureTime = v
+		case "arrival_time":
+			flight.ArrivalTime = v
+		case "additional_info":
+			flight.AdditionalInfo = v
+		case "route":
+			flight.Route = v
+		}
+	case float64:
+		switch field {
+		case "duration":
+			flight.Duration = v
+		case "price":
+			flight.Price = v
+		}
+	case int:
+		if field == "stops" {
+			flight.Stops = v
+		}
+	}
+}
+
+// returns a flight field as text - the counterpart of setFlightField, used for dedup keys
+func flightFieldValue(flight *models.Flight, field string) string {
+	switch field {
+	case "airline":
+		return flight.Airline
+	case "flight_date":
+		return flight.FlightDate
+	case "source":
+		return flight.Source
+	case "destination":
+		return flight.Destination
+	case "flight_class":
+		return flight.FlightClass
+	case "duration":
+		return strconv.FormatFloat(flight.Duration, 'f', -1, 64)
+	case "price":
+		return strconv.FormatFloat(flight.Price, 'f', -1, 64)
+	case "departure_time":
+		return flight.DepartureTime
+	case "arrival_time":
+		return flight.ArrivalTime
+	case "stops":
+		return strconv.Itoa(flight.Stops)
+	case "additional_info":
+		return flight.AdditionalInfo
+	case "route":
+		return flight.Route
+	}
+	return ""
+}
+
+// parses a duration in the configured format and returns hours
+func parseDurationFormat(raw, format string) (float64, bool) {
+	switch format {
+	case "hours":
+		hours, err := strconv.ParseFloat(raw, 64)
+		return hours, err == nil
+	case "minutes":
+		minutes, err := strconv.ParseFloat(raw, 64)
+		return minutes / 60, err == nil
+	case "hh:mm":
+		h, m, found := strings.Cut(raw, ":")
+		hours, errH := strconv.Atoi(h)
+		minutes, errM := strconv.Atoi(m)
+		if !found || errH != nil || errM != nil {
+			return 0, false
+		}
+		return float64(hours) + float64(minutes)/60, true
+	}
+	hours := parseDuration(raw)
+	return hours, hours > 0
+}
+
+// parses a price written in the given locale - currency symbols and spaces are ignored
+func parsePriceLocale(raw, locale string) (float64, bool) {
+	if locale == "eu" {
+		// 1.234,50 -> 1234.50
+		raw = strings.ReplaceAll(raw, ".", "")
+		raw = strings.ReplaceAll(raw, ",", ".")
+	} else {
+		// en-IN groups as 1,23,456.50 - the commas just go away
+		raw = strings.ReplaceAll(raw, ",", "")
+	}
+	price, err := strconv.ParseFloat(cleanNonNumeric(raw), 64)
+	return price, err == nil
+}
+
+// parses stop counts like "2", "2 stops", "non-stop", "zero", "one" or "two_or_more"
+func parseStops(raw string) (int, bool) {
+	lower := strings.ToLower(raw)
+	switch {
+	case lower == "non-stop" || lower == "nonstop" || lower == "zero":
+		return 0, true
+	case lower == "one":
+		return 1, true
+	case strings.HasPrefix(lower, "two"):
+		return 2, true
+	}
+	stops, err := strconv.Atoi(cleanNonNumeric(lower))
+	return stops, err == nil
+}
+
+// the mapping we use when data/column_schema.json isn't there - matches the headers we've always accepted
+func defaultColumnSchema() *ColumnSchema {
+	dedup := defaultDedupConfig()
+	return &ColumnSchema{Dedup: &dedup, Fields: map[string]FieldSpec{
+		"airline":         {Headers: []string{"airline"}},
+		"flight_date":     {Headers: []string{"date_of_journey", "flight_date", "flight date"}, Parser: "date", Layouts: []string{"2/1/2006", "2006-01-02"}},
+		"source":          {Headers: []string{"source", "source_city", "from_city", "from"}, Required: true},
+		"destination":     {Headers: []string{"destination", "destination_city", "to_city", "to"}, Required: true},
+		"flight_class":    {Headers: []string{"class", "flight_class"}},
+		"duration":        {Headers: []string{"duration"}, Parser: "duration", Format: "auto"},
+		"price":           {Headers: []string{"price"}, Parser: "price", Locale: "en-IN"},
+		"departure_time":  {Headers: []string{"departure_time", "dep_time"}},
+		"arrival_time":    {Headers: []string{"arrival_time", "arr_time"}},
+		"stops":           {Headers: []string{"stops", "total_stops"}, Parser: "stops"},
+		"additional_info": {Headers: []string{"additional_info", "info"}},
+		"route":           {Headers: []string{"route"}},
+	}}
+}
@@ -0,0 +1,137 @@
+package services
+
+import (
+	"encoding/json"
+	"log"
+	"os"
+	"strings"
+
+	"flight-dashboard-backend/models"
+)
+
+// country -> cities outside India that show up in datasets
+const cityCountryMapPath = "data/city_country_map.json"
+
+// every city the state mapping knows is in this country
+const domesticCountry = "India"
+
+// how a flight is classified by the countries of its two ends
+const (
+	FlightDomestic      = "domestic"      // both ends in India
+	FlightInternational = "international" // one end in India, the other abroad
+	FlightForeign       = "foreign"       // neither end in India
+	FlightUnclassified  = "unknown"       // an end we can't place in any country
+)
+
+// a city outside India and the country it's in
+type ForeignCity struct {
+	City    string `json:"city"`
+	Country string `json:"country"`
+}
+
+// used when data/city_country_map.json doesn't exist
+var defaultCityCountryLists = map[string][]string{
+	"Bangladesh":           {"dhaka"},
+	"Bhutan":               {"paro", "thimphu"},
+	"Malaysia":             {"kuala lumpur"},
+	"Maldives":             {"male"},
+	"Nepal":                {"kathmandu", "pokhara"},
+	"Oman":                 {"muscat"},
+	"Qatar":                {"doha"},
+	"Saudi Arabia":         {"riyadh", "jeddah"},
+	"Singapore":            {"singapore"},
+	"Sri Lanka":            {"colombo"},
+	"Thailand":             {"bangkok"},
+	"United Arab Emirates": {"dubai", "abu dhabi", "sharjah"},
+	"United Kingdom":       {"london"},
+}
+
+// loads the cities outside India - read once at startup, so lookups need no lock
+func (csm *CityStateMapper) loadCityCountryMap() {
+	lists := defaultCityCountryLists
+	data, err := os.ReadFile(cityCountryMapPath)
+	if err != nil {
+		log.Println("Could not load city-country map from JSON file, using default mapping:", err)
+	} else {
+		var rawMap map[string][]string
+		if err := json.Unmarshal(data, &rawMap); err != nil {
+			log.Printf("Error parsing city-country map JSON: %v, using default mapping", err)
+		} else {
+			lists = rawMap
+		}
+	}
+
+	csm.cityToCountry = make(map[string]string)
+	for country, cities := range lists {
+		country = strings.TrimSpace(country)
+		for _, city := range cities {
+			city = strings.ToLower(strings.TrimSpace(city))
+			if existing, taken := csm.cityToCountry[city]; taken && existing != country {
+				log.Printf("Warning: %q is listed under %s and %s, keeping %s", city, existing, country, existing)
+				continue
+			}
+			csm.cityToCountry[city] = country
+		}
+	}
+	log.Printf("Loaded %d cities outside India", len(csm.cityToCountry))
+}
+
+// finds a city outside India by name or by the code of a foreign airport
+// cities the state mapping knows are never foreign, even when the names clash
+func (csm *CityStateMapper) ResolveForeignCity(city string) (ForeignCity, bool) {
+	if _, ok := csm.ResolveCity(city); ok {
+		return ForeignCity{}, false
+	}
+	return csm.lookupForeignCity(city)
+}
+
+// foreign lookup without checking the state mapping first - ResolveCity uses it to stop fuzzy matching abroad
+func (csm *CityStateMapper) lookupForeignCity(city string) (ForeignCity, bool) {
+	normalizedCity := strings.ToLower(strings.TrimSpace(city))
+	if normalizedCity == "" {
+		return ForeignCity{}, false
+	}
+	if country, exists := csm.cityToCountry[normalizedCity]; exists {
+		return ForeignCity{City: normalizedCity, Country: country}, true
+	}
+	if airport, exists := GetAirportRegistry().GetAirport(city); exists && !strings.EqualFold(airport.Country, domesticCountry) {
+		return ForeignCity{City: strings.ToLower(airport.City), Country: airport.Country}, true
+	}
+	return ForeignCity{}, false
+}
+
+// returns the country a city is in - "India" for every city the state mapping resolves
+func (csm *CityStateMapper) GetCountryForCity(city string) (string, bool) {
+	if _, ok := csm.ResolveCity(city); ok {
+		return domesticCountry, true
+	}
+	if foreign, ok := csm.lookupForeignCity(city); ok {
+		return foreign.Country, true
+	}
+	return "", false
+}
+
+// classifies a flight as domestic, international, foreign or unknown by the countries of its ends
+func (csm *CityStateMapper) ClassifyFlight(flight *models.Flight) string {
+	sourceCountry, sourceOk := csm.GetCountryForCity(flight.Source)
+	destCountry, destOk := csm.GetCountryForCity(flight.Destination)
+	if !sourceOk || !destOk {
+		return FlightUnclassified
+	}
+	sourceDomestic := sourceCountry == domesticCountry
+	destDomestic := destCountry == domesticCountry
+	switch {
+	case sourceDomestic && destDomestic:
+		return FlightDomestic
+	case sourceDomestic || destDomestic:
+		return FlightInternational
+	default:
+		return FlightForeign
+	}
+}
+
+// tells if a city is a known city outside India
+func (csm *CityStateMapper) isForeignCity(city string) bool {
+	_, ok := csm.lookupForeignCity(city)
+	return ok
+}
@@ -0,0 +1,167 @@
+package services
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"flight-dashboard-backend/models"
+)
+
+// expands the dataset path into the files to load - a single file, every dataset file in a
+// directory, or every dataset file matching a glob like "data/flights_*.csv.gz"
+func resolveDatasetFiles(path string) ([]string, error) {
+	if strings.ContainsAny(path, "*?[") {
+		matches, err := filepath.Glob(path)
+		if err != nil {
+			return nil, fmt.Errorf("bad dataset pattern %s: %v", path, err)
+		}
+		files := filterDatasetFiles(matches)
+		if len(files) == 0 {
+			return nil, fmt.Errorf("no dataset files match %s", path)
+		}
+		return files, nil
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open %s: %v", path, err)
+	}
+	if !info.IsDir() {
+		return []string{path}, nil
+	}
+
+	entries, err := os.ReadDir(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read directory %s: %v", path, err)
+	}
+	matches := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		matches = append(matches, filepath.Join(path, entry.Name()))
+	}
+	files := filterDatasetFiles(matches)
+	if len(files) == 0 {
+		return nil, fmt.Errorf("no dataset files in %s", path)
+	}
+	return files, nil
+}
+
+// keeps regular, non-hidden files with an extension we can decode, sorted by name
+func filterDatasetFiles(paths []string) []string {
+	var files []string
+	for _, path := range paths {
+		base := filepath.Base(path)
+		if strings.HasPrefix(base, ".") || !IsSupportedDatasetFile(base) {
+			continue
+		}
+		if info, err := os.Stat(path); err != nil || info.IsDir() {
+			continue
+		}
+		files = append(files, path)
+	}
+	sort.Strings(files)
+	return files
+}
+
+// loads every file behind the dataset path concurrently and merges them into one dataset
+// a file that can't be read at all is recorded in the report and skipped, the rest still load
+func readFlightsFromPath(path string) ([]models.Flight, IngestionReport, error) {
+	report := IngestionReport{Source: path, Issues: []IngestionIssue{}, Files: []FileIngestionSummary{}}
+
+	files, err := resolveDatasetFiles(path)
+	if err != nil {
+		return nil, report, err
+	}
+
+	type fileResult struct {
+		flights []models.Flight
+		report  IngestionReport
+		err     error
+	}
+	results := make([]fileResult, len(files))
+
+	// a few files at a time - parsing is CPU bound and each file is held in memory while it loads
+	var wg sync.WaitGroup
+	slots := make(chan struct{}, runtime.NumCPU())
+	for i, file := range files {
+		wg.Add(1)
+		go func(i int, file string) {
+			defer wg.Done()
+			slots <- struct{}{}
+			defer func() { <-slots }()
+
+			flights, fileReport, err := readFlightsFromFile(file)
+			for j := range flights {
+				flights[j].SourceFile = file
+			}
+			results[i] = fileResult{flights: flights, report: fileReport, err: err}
+		}(i, file)
+	}
+	wg.Wait()
+
+	// merging in file name order so the result doesn't depend on which goroutine finished first
+	total := 0
+	for _, result := range results {
+		total += len(result.flights)
+	}
+	flights := make([]models.Flight, 0, total)
+	loadedFiles := 0
+	for i, result := range results {
+		summary := FileIngestionSummary{File: files[i]}
+		if result.err != nil {
+			summary.Error = result.err.Error()
+			log.Printf("Warning: Could not load %s: %v", files[i], result.err)
+		} else {
+			loadedFiles++
+			summary.AcceptedRows = result.report.AcceptedRows
+			summary.RejectedRows = result.report.RejectedRows
+			summary.CoercedRows = result.report.CoercedRows
+			summary.FlaggedRows = result.report.FlaggedRows
+			report.merge(result.report, files[i])
+			flights = append(flights, result.flights...)
+		}
+		report.Files = append(report.Files, summary)
+	}
+
+	if loadedFiles == 0 {
+		return nil, report, fmt.Errorf("%w: none of the %d dataset files could be loaded", ErrInvalidDataset, len(files))
+	}
+
+	// dedup runs on the merged data so repeats across files are caught too
+	dedup := *GetColumnSchema().Dedup
+	flights, report.Duplicates = deduplicateFlights(flights, dedup)
+	report.DedupStrategy = dedup.Strategy
+	if dedup.Strategy != DedupFlag {
+		report.DuplicatesRemoved = report.Duplicates
+	}
+	report.AcceptedRows = len(flights)
+	if report.Duplicates > 0 {
+		log.Printf("Found %d duplicate flights in %s (strategy: %s)", report.Duplicates, path, dedup.Strategy)
+	}
+
+	report.LoadedAt = time.Now()
+	return flights, report, nil
+}
+
+// describes the files behind the dataset path - changes whenever a file is added, removed or rewritten
+func datasetFingerprint(path string) string {
+	files, err := resolveDatasetFiles(path)
+	if err != nil {
+		return ""
+	}
+	var fingerprint strings.Builder
+	for _, file := range files {
+		info, err := os.Stat(file)
+		if err != nil {
+			continue
+		}
+		fmt.Fprintf(&fingerprint, "%s|%d|%d\n", file, info.Size(), info.ModTime().UnixNano())
+	}
+	return fingerprint.String()
+}
@@ -0,0 +1,545 @@
+package services
+
+import (
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/xitongsys/parquet-go-source/buffer"
+	"github.com/xitongsys/parquet-go/parquet"
+	"github.com/xitongsys/parquet-go/reader"
+)
+
+// every dataset format boils down to a header plus string records, which the column schema turns into flights
+type RecordReader interface {
+	// column names of the dataset
+	Header() []string
+	// returns the next record and the line (or row) it came from, io.EOF once there are no more
+	// a *RowDecodeError means only that row is broken and reading can carry on
+	Read() ([]string, int, error)
+}
+
+// one row that couldn't be decoded - it goes into the ingestion report and the load continues
+type RowDecodeError struct {
+	Line int
+	Raw  string
+	Err  error
+}
+
+func (e *RowDecodeError) Error() string {
+	return e.Err.Error()
+}
+
+// a dataset format we know how to decode
+type recordFormat struct {
+	name       string
+	extensions []string
+	sniff      func(peek []byte) bool
+	open       func(r io.Reader) (RecordReader, error)
+}
+
+// a compression wrapper we know how to unpack
+type compressionFormat struct {
+	name       string
+	extensions []string
+	magic      []byte
+	open       func(r io.Reader) (io.Reader, string, func(), error) // unpacked stream, inner file name (if any), cleanup
+}
+
+// formats in sniffing order - csv goes last because anything textual can pass for csv
+var recordFormats = []recordFormat{
+	{name: "parquet", extensions: []string{".parquet", ".pq"}, sniff: sniffParquet, open: newParquetRecordReader},
+	{name: "ndjson", extensions: []string{".jsonl", ".ndjson", ".json"}, sniff: sniffNDJSON, open: newNDJSONRecordReader},
+	{name: "tsv", extensions: []string{".tsv", ".tab"}, sniff: sniffTSV, open: func(r io.Reader) (RecordReader, error) { return newCSVRecordReader(r, '\t') }},
+	{name: "csv", extensions: []string{".csv", ".txt"}, sniff: func([]byte) bool { return true }, open: func(r io.Reader) (RecordReader, error) { return newCSVRecordReader(r, ',') }},
+}
+
+var compressionFormats = []compressionFormat{
+	{name: "gzip", extensions: []string{".gz", ".gzip"}, magic: []byte{0x1f, 0x8b}, open: openGzip},
+	{name: "zstd", extensions: []string{".zst", ".zstd"}, magic: []byte{0x28, 0xb5, 0x2f, 0xfd}, open: openZstd},
+	{name: "zip", extensions: []string{".zip"}, magic: []byte("PK\x03\x04"), open: openZip},
+}
+
+// tells if a file name looks like something openRecordReader can decode
+func IsSupportedDatasetFile(name string) bool {
+	name = strings.ToLower(name)
+	for _, compression := range compressionFormats {
+		for _, ext := range compression.extensions {
+			name = strings.TrimSuffix(name, ext)
+		}
+	}
+	ext := filepath.Ext(name)
+	for _, format := range recordFormats {
+		for _, known := range format.extensions {
+			if ext == known {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// picks the decompressor and decoder for a dataset - magic bytes win, then the file extension,
+// then a look at the content. the returned cleanup func must be called once reading is done
+func openRecordReader(name string, r io.Reader) (RecordReader, func(), error) {
+	cleanup := func() {}
+	buffered := bufio.NewReaderSize(r, 64*1024)
+	peek, _ := buffered.Peek(512)
+	var stream io.Reader = buffered
+
+	for _, compression := range compressionFormats {
+		if !bytes.HasPrefix(peek, compression.magic) {
+			continue
+		}
+		unpacked, innerName, closeFn, err := compression.open(buffered)
+		if err != nil {
+			return nil, cleanup, fmt.Errorf("%w: failed to open %s data in %s: %v", ErrInvalidDataset, compression.name, name, err)
+		}
+		cleanup = closeFn
+		name = stripExtensions(name, compression.extensions)
+		if innerName != "" {
+			name = innerName
+		}
+		inner := bufio.NewReaderSize(unpacked, 64*1024)
+		peek, _ = inner.Peek(512)
+		stream = inner
+		break
+	}
+
+	format := detectRecordFormat(name, peek)
+	records, err := format.open(stream)
+	if err != nil {
+		cleanup()
+		return nil, func() {}, err
+	}
+	return records, cleanup, nil
+}
+
+// parquet is recognised by its magic bytes, otherwise the extension decides and sniffing is the fallback
+func detectRecordFormat(name string, peek []byte) recordFormat {
+	if sniffParquet(peek) {
+		return recordFormats[0]
+	}
+	ext := strings.ToLower(filepath.Ext(name))
+	for _, format := range recordFormats {
+		for _, known := range format.extensions {
+			if ext == known {
+				return format
+			}
+		}
+	}
+	for _, format := range recordFormats {
+		if format.sniff(peek) {
+			return format
+		}
+	}
+	return recordFormats[len(recordFormats)-1]
+}
+
+func stripExtensions(name string, extensions []string) string {
+	for _, ext := range extensions {
+		if strings.HasSuffix(strings.ToLower(name), ext) {
+			return name[:len(name)-len(ext)]
+		}
+	}
+	return name
+}
+
+func sniffParquet(peek []byte) bool {
+	return bytes.HasPrefix(peek, []byte("PAR1"))
+}
+
+func sniffNDJSON(peek []byte) bool {
+	trimmed := bytes.TrimLeft(peek, " \t\r\n\ufeff")
+	return len(trimmed) > 0 && (trimmed[0] == '{' || trimmed[0] == '[')
+}
+
+func sniffTSV(peek []byte) bool {
+	firstLine, _, _ := bytes.Cut(peek, []byte("\n"))
+	return bytes.Contains(firstLine, []byte("\t")) && !bytes.Contains(firstLine, []byte(","))
+}
+
+func openGzip(r io.Reader) (io.Reader, string, func(), error) {
+	gz, err := gzip.NewReader(r)
+	if err != nil {
+		return nil, "", nil, err
+	}
+	return gz, "", func() { gz.Close() }, nil
+}
+
+func openZstd(r io.Reader) (io.Reader, string, func(), error) {
+	decoder, err := zstd.NewReader(r)
+	if err != nil {
+		return nil, "", nil, err
+	}
+	return decoder, "", decoder.Close, nil
+}
+
+// zip needs random access, so the archive is read into memory and its first data file is used
+func openZip(r io.Reader) (io.Reader, string, func(), error) {
+	content, err := io.ReadAll(r)
+	if err != nil {
+		return nil, "", nil, err
+	}
+	archive, err := zip.NewReader(bytes.NewReader(content), int64(len(content)))
+	if err != nil {
+		return nil, "", nil, err
+	}
+	for _, file := range archive.File {
+		base := filepath.Base(file.Name)
+		if file.FileInfo().IsDir() || strings.HasPrefix(file.Name, "__MACOSX/") || strings.HasPrefix(base, ".") {
+			continue
+		}
+		entry, err := file.Open()
+		if err != nil {
+			return nil, "", nil, err
+		}
+		return entry, file.Name, func() { entry.Close() }, nil
+	}
+	return nil, "", nil, errors.New("archive has no data file")
+}
+
+// csv and tsv - both go through encoding/csv with a different separator
+type csvRecordReader struct {
+	reader *csv.Reader
+	header []string
+}
+
+func newCSVRecordReader(r io.Reader, comma rune) (RecordReader, error) {
+	reader := csv.NewReader(r)
+	reader.Comma = comma
+	if comma == '\t' {
+		// tab separated exports rarely quote properly
+		reader.LazyQuotes = true
+	}
+
+	// Read header to understand column order
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("%w: failed to read header: %v", ErrInvalidDataset, err)
+	}
+	return &csvRecordReader{reader: reader, header: header}, nil
+}
+
+func (cr *csvRecordReader) Header() []string {
+	return cr.header
+}
+
+func (cr *csvRecordReader) Read() ([]string, int, error) {
+	record, err := cr.reader.Read()
+	if err == io.EOF {
+		return nil, 0, io.EOF
+	}
+	if err != nil {
+		var parseErr *csv.ParseError
+		if errors.As(err, &parseErr) {
+			return nil, parseErr.Line, &RowDecodeError{Line: parseErr.Line, Raw: strings.Join(record, string(cr.reader.Comma)), Err: err}
+		}
+		return nil, 0, err
+	}
+	line, _ := cr.reader.FieldPos(0)
+	return record, line, nil
+}
+
+// newline delimited JSON (one flat object per line) or a JSON array of flat objects
+// objects may leave keys out, so the columns come from the column schema rather than from any one object:
+// there's a column per schema field, and each object fills it from the first of the field's header names it has
+type ndjsonRecordReader struct {
+	scanner *bufio.Scanner      // line by line for ndjson
+	array   *json.Decoder       // element by element for a JSON array, element numbers stand in for line numbers
+	fields  []string            // schema field of each column
+	headers map[string][]string // lower-case header names of each field, in the schema's order
+	known   map[string]bool     // every lower-case header name of the schema
+	header  []string
+	line    int
+	unknown map[string]bool // keys no schema field claims, logged once the file is read
+}
+
+func newNDJSONRecordReader(r io.Reader) (RecordReader, error) {
+	buffered := bufio.NewReaderSize(r, 64*1024)
+	peek, _ := buffered.Peek(512)
+	peek = bytes.TrimLeft(bytes.TrimPrefix(bytes.TrimLeft(peek, " \t\r\n"), []byte("\ufeff")), " \t\r\n")
+
+	schema := GetColumnSchema()
+	nr := &ndjsonRecordReader{headers: make(map[string][]string), known: make(map[string]bool), unknown: make(map[string]bool)}
+	for _, field := range schemaFields {
+		spec, exists := schema.Fields[field]
+		if !exists || len(spec.Headers) == 0 {
+			continue
+		}
+		nr.fields = append(nr.fields, field)
+		nr.header = append(nr.header, spec.Headers[0])
+		for _, name := range spec.Headers {
+			name = strings.ToLower(strings.TrimSpace(name))
+			nr.headers[field] = append(nr.headers[field], name)
+			nr.known[name] = true
+		}
+	}
+
+	if len(peek) > 0 && peek[0] == '[' {
+		// the decoder doesn't skip a BOM, so that goes first
+		if bom, _ := buffered.Peek(3); bytes.Equal(bom, []byte("\ufeff")) {
+			buffered.Discard(3)
+		}
+		nr.array = json.NewDecoder(buffered)
+		if token, err := nr.array.Token(); err != nil || token != json.Delim('[') {
+			return nil, fmt.Errorf("%w: not a JSON array of objects", ErrInvalidDataset)
+		}
+		nr.array.UseNumber()
+		return nr, nil
+	}
+	if len(peek) > 0 && peek[0] != '{' {
+		return nil, fmt.Errorf("%w: not a JSON object or array of objects", ErrInvalidDataset)
+	}
+
+	nr.scanner = bufio.NewScanner(buffered)
+	nr.scanner.Buffer(make([]byte, 0, 64*1024), 4*1024*1024)
+	return nr, nil
+}
+
+func (nr *ndjsonRecordReader) Header() []string {
+	return nr.header
+}
+
+func (nr *ndjsonRecordReader) Read() ([]string, int, error) {
+	if nr.array != nil {
+		return nr.readArrayElement()
+	}
+
+	for nr.scanner.Scan() {
+		nr.line++
+		text := strings.TrimSpace(nr.scanner.Text())
+		if nr.line == 1 {
+			text = strings.TrimPrefix(text, "\ufeff")
+		}
+		if text == "" {
+			continue
+		}
+		object, err := decodeJSONObject(text)
+		if err != nil {
+			return nil, nr.line, &RowDecodeError{Line: nr.line, Raw: text, Err: fmt.Errorf("invalid JSON: %v", err)}
+		}
+		return nr.toRecord(object), nr.line, nil
+	}
+	if err := nr.scanner.Err(); err != nil {
+		return nil, 0, err
+	}
+	nr.logUnknownKeys()
+	return nil, 0, io.EOF
+}
+
+// the next object of a JSON array - an element that isn't an object is skipped, broken JSON ends the file
+func (nr *ndjsonRecordReader) readArrayElement() ([]string, int, error) {
+	if !nr.array.More() {
+		nr.logUnknownKeys()
+		return nil, 0, io.EOF
+	}
+	nr.line++
+	var element interface{}
+	if err := nr.array.Decode(&element); err != nil {
+		return nil, nr.line, fmt.Errorf("%w: element %d of the JSON array is invalid: %v", ErrInvalidDataset, nr.line, err)
+	}
+	object, ok := element.(map[string]interface{})
+	if !ok {
+		raw, _ := json.Marshal(element)
+		return nil, nr.line, &RowDecodeError{Line: nr.line, Raw: string(raw), Err: errors.New("array element is not a JSON object")}
+	}
+	return nr.toRecord(object), nr.line, nil
+}
+
+// lines an object up with the schema columns - each field takes the first of its header names the object has
+func (nr *ndjsonRecordReader) toRecord(object map[string]interface{}) []string {
+	keys := make(map[string]string, len(object))
+	for key := range object {
+		keys[strings.ToLower(strings.TrimSpace(key))] = key
+	}
+
+	record := make([]string, len(nr.fields))
+	for i, field := range nr.fields {
+		for _, name := range nr.headers[field] {
+			if key, exists := keys[name]; exists {
+				record[i] = jsonValueString(object[key])
+				break
+			}
+		}
+	}
+	for name, key := range keys {
+		if !nr.known[name] {
+			nr.unknown[key] = true
+		}
+	}
+	return record
+}
+
+// same as the unmapped columns of a csv header
+func (nr *ndjsonRecordReader) logUnknownKeys() {
+	if len(nr.unknown) == 0 {
+		return
+	}
+	keys := make([]string, 0, len(nr.unknown))
+	for key := range nr.unknown {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	log.Printf("Keys not mapped by the column schema, ignoring: %s", strings.Join(keys, ", "))
+	nr.unknown = nil
+}
+
+func jsonValueString(value interface{}) string {
+	switch value := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return value
+	case json.Number:
+		return value.String()
+	case bool:
+		return strconv.FormatBool(value)
+	default:
+		raw, _ := json.Marshal(value)
+		return string(raw)
+	}
+}
+
+func decodeJSONObject(text string) (map[string]interface{}, error) {
+	decoder := json.NewDecoder(strings.NewReader(text))
+	decoder.UseNumber()
+	var object map[string]interface{}
+	if err := decoder.Decode(&object); err != nil {
+		return nil, err
+	}
+	if object == nil {
+		return nil, errors.New("not an object")
+	}
+	return object, nil
+}
+
+// how many parquet rows we pull into memory at a time
+const parquetBatchSize = 1000
+
+// parquet - only flat schemas, row numbers stand in for line numbers
+type parquetRecordReader struct {
+	reader  *reader.ParquetReader
+	header  []string // column names as written in the file
+	fields  []string // matching field names on the row structs parquet-go generates
+	isDate  []bool   // DATE columns come out as days since epoch
+	batch   []interface{}
+	row     int
+	numRows int
+}
+
+func newParquetRecordReader(r io.Reader) (_ RecordReader, err error) {
+	// parquet-go panics on some corrupt files, so turn that into a normal error
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			err = fmt.Errorf("%w: unreadable parquet file: %v", ErrInvalidDataset, recovered)
+		}
+	}()
+
+	content, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	file, err := buffer.NewBufferFile(content)
+	if err != nil {
+		return nil, err
+	}
+	pr, err := reader.NewParquetReader(file, nil, 1)
+	if err != nil {
+		return nil, fmt.Errorf("%w: unreadable parquet file: %v", ErrInvalidDataset, err)
+	}
+
+	pq := &parquetRecordReader{reader: pr, numRows: int(pr.GetNumRows())}
+	elements := pr.SchemaHandler.SchemaElements
+	for i := 1; i < len(elements); i++ {
+		if elements[i].GetNumChildren() > 0 {
+			pr.ReadStop()
+			return nil, fmt.Errorf("%w: nested parquet column %s is not supported", ErrInvalidDataset, elements[i].GetName())
+		}
+		pq.header = append(pq.header, pr.SchemaHandler.Infos[i].ExName)
+		pq.fields = append(pq.fields, pr.SchemaHandler.Infos[i].InName)
+		pq.isDate = append(pq.isDate, elements[i].IsSetConvertedType() && elements[i].GetConvertedType() == parquet.ConvertedType_DATE)
+	}
+	return pq, nil
+}
+
+func (pq *parquetRecordReader) Header() []string {
+	return pq.header
+}
+
+func (pq *parquetRecordReader) Read() (_ []string, _ int, err error) {
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			err = fmt.Errorf("failed to read parquet rows: %v", recovered)
+		}
+	}()
+
+	if pq.row >= pq.numRows {
+		pq.reader.ReadStop()
+		return nil, 0, io.EOF
+	}
+	if len(pq.batch) == 0 {
+		count := parquetBatchSize
+		if remaining := pq.numRows - pq.row; remaining < count {
+			count = remaining
+		}
+		pq.batch, err = pq.reader.ReadByNumber(count)
+		if err != nil {
+			return nil, 0, fmt.Errorf("failed to read parquet rows: %v", err)
+		}
+		if len(pq.batch) == 0 {
+			return nil, 0, io.EOF
+		}
+	}
+
+	row := reflect.ValueOf(pq.batch[0])
+	pq.batch = pq.batch[1:]
+	pq.row++
+
+	record := make([]string, len(pq.fields))
+	for i, name := range pq.fields {
+		record[i] = parquetValueString(row.FieldByName(name), pq.isDate[i])
+	}
+	return record, pq.row, nil
+}
+
+// converts one parquet value to the string the column schema parsers expect
+func parquetValueString(value reflect.Value, isDate bool) string {
+	if !value.IsValid() {
+		return ""
+	}
+	if value.Kind() == reflect.Ptr {
+		if value.IsNil() {
+			return ""
+		}
+		value = value.Elem()
+	}
+	switch value.Kind() {
+	case reflect.String:
+		return value.String()
+	case reflect.Int32, reflect.Int64, reflect.Int:
+		if isDate {
+			return time.Unix(value.Int()*24*60*60, 0).UTC().Format("2006-01-02")
+		}
+		return strconv.FormatInt(value.Int(), 10)
+	case reflect.Float32, reflect.Float64:
+		return strconv.FormatFloat(value.Float(), 'f', -1, 64)
+	case reflect.Bool:
+		return strconv.FormatBool(value.Bool())
+	}
+	return fmt.Sprint(value.Interface())
+}
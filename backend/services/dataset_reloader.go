@@ -0,0 +1,267 @@
+package services
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"flight-dashboard-backend/models"
+)
+
+// this reloader swaps in a fresh dataset file without restarting the server
+type DatasetReloader struct {
+	dataService *FlightDataService
+	aggregator  *StateAggregator
+	path        string     // file, directory or glob
+	fingerprint string     // names, sizes and mod times of the files we loaded last
+	reloadMutex sync.Mutex // makes sure only one reload runs at a time
+}
+
+// global instance of the dataset reloader
+var datasetReloader *DatasetReloader
+var reloaderOnce sync.Once
+
+// returns singleton instance of the dataset reloader
+func GetDatasetReloader() *DatasetReloader {
+	reloaderOnce.Do(func() {
+		datasetReloader = &DatasetReloader{
+			dataService: GetFlightDataService(),
+			aggregator:  GetStateAggregator(),
+		}
+	})
+	return datasetReloader
+}
+
+// parses the dataset files in the background and swaps flights and aggregations in one go
+// the old data stays in place if the new files can't be read or have no usable records
+func (dr *DatasetReloader) Reload() error {
+	dr.reloadMutex.Lock()
+	defer dr.reloadMutex.Unlock()
+
+	if dr.path == "" {
+		return fmt.Errorf("no dataset path configured")
+	}
+
+	// remembering what we tried to load so a broken file isn't retried until it changes again
+	dr.fingerprint = datasetFingerprint(dr.path)
+
+	flights, report, err := readFlightsFromPath(dr.path)
+	if err != nil {
+		return err
+	}
+	if len(flights) == 0 {
+		return fmt.Errorf("%w: no valid flight records in %s, keeping current dataset", ErrInvalidDataset, dr.path)
+	}
+
+	dr.swap(flights, report)
+	return nil
+}
+
+// validates an uploaded dataset, writes it into the dataset location and reloads
+// a single-file dataset gets overwritten, a directory or glob dataset gets the upload added under its own name
+// nothing on disk or in memory changes unless the upload has at least one valid row
+func (dr *DatasetReloader) Publish(r io.Reader, name string) (IngestionReport, error) {
+	dr.reloadMutex.Lock()
+	defer dr.reloadMutex.Unlock()
+
+	if dr.path == "" {
+		return IngestionReport{}, fmt.Errorf("no dataset path configured")
+	}
+
+	content, err := io.ReadAll(r)
+	if err != nil {
+		return IngestionReport{}, fmt.Errorf("failed to read upload: %v", err)
+	}
+
+	flights, report, err := readFlightsFromReader(bytes.NewReader(content), name)
+	if err != nil {
+		return report, err
+	}
+	if len(flights) == 0 {
+		return report, fmt.Errorf("%w: no valid flight records in %s", ErrInvalidDataset, name)
+	}
+
+	target, err := dr.uploadTarget(name)
+	if err != nil {
+		return report, err
+	}
+
+	// persisting first so the upload survives a restart, then making it live
+	if err := writeFileAtomically(target, content); err != nil {
+		return report, err
+	}
+	dr.fingerprint = datasetFingerprint(dr.path)
+
+	// reading back from disk so the upload gets merged with any other dataset files
+	allFlights, fullReport, err := readFlightsFromPath(dr.path)
+	if err != nil {
+		return report, err
+	}
+	dr.swap(allFlights, fullReport)
+	return report, nil
+}
+
+// works out where an upload goes - the dataset file itself, or a file inside the dataset directory/glob
+func (dr *DatasetReloader) uploadTarget(name string) (string, error) {
+	base := filepath.Base(filepath.Clean("/" + name))
+	if base == "/" || strings.HasPrefix(base, ".") {
+		return "", fmt.Errorf("%w: bad file name %q", ErrInvalidDataset, name)
+	}
+
+	if strings.ContainsAny(dr.path, "*?[") {
+		target := filepath.Join(filepath.Dir(dr.path), base)
+		if matched, _ := filepath.Match(dr.path, target); !matched {
+			return "", fmt.Errorf("%w: %s doesn't match the dataset pattern %s", ErrInvalidDataset, base, filepath.Base(dr.path))
+		}
+		return target, nil
+	}
+	if info, err := os.Stat(dr.path); err == nil && info.IsDir() {
+		return filepath.Join(dr.path, base), nil
+	}
+	return dr.path, nil
+}
+
+// swaps flights and aggregations together so readers never see a mix of old and new data
+// callers must hold reloadMutex
+func (dr *DatasetReloader) swap(flights []models.Flight, report IngestionReport) {
+	// computing the new aggregations before taking any lock so readers are never blocked by it
+	set := dr.aggregator.buildAggregationSet(flights)
+
+	// lock order is always data service first, then aggregator
+	dr.dataService.mutex.Lock()
+	dr.aggregator.mutex.Lock()
+	dr.dataService.flights = flights
+	dr.dataService.dataPath = dr.path
+	dr.dataService.report = report
+	dr.aggregator.setAggregations(set)
+	dr.aggregator.mutex.Unlock()
+	dr.dataService.mutex.Unlock()
+
+	log.Printf("Reloaded %d flight records from %s (%d rejected, %d states aggregated)",
+		report.AcceptedRows, report.Source, report.RejectedRows, len(set.states))
+}
+
+// rebuilds the aggregations from the flights currently loaded - for changes that affect how flights
+// are aggregated but not the flights themselves, like a mapping edit
+// holding reloadMutex keeps a reload from swapping in new flights between the read and the swap
+func (dr *DatasetReloader) Recompute() {
+	dr.reloadMutex.Lock()
+	defer dr.reloadMutex.Unlock()
+
+	flights := dr.dataService.GetAllFlights()
+	set := dr.aggregator.buildAggregationSet(flights)
+
+	// same locks and order as swap
+	dr.dataService.mutex.Lock()
+	dr.aggregator.mutex.Lock()
+	dr.aggregator.setAggregations(set)
+	dr.aggregator.mutex.Unlock()
+	dr.dataService.mutex.Unlock()
+
+	log.Printf("Recomputed aggregations for %d flight records (%d states aggregated)", len(flights), len(set.states))
+}
+
+// writes to a temp file next to the target and renames it over, so the watcher never sees half a file
+func writeFileAtomically(path string, content []byte) error {
+	tmp, err := writeTempFile(path, content)
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp)
+
+	if err := os.Rename(tmp, path); err != nil {
+		return fmt.Errorf("failed to replace %s: %v", path, err)
+	}
+	return nil
+}
+
+// writes content to a synced temp file next to path and returns its name - renaming it over path is up to the caller
+func writeTempFile(path string, content []byte) (string, error) {
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return "", fmt.Errorf("failed to create temp file for %s: %v", path, err)
+	}
+
+	if _, err := tmp.Write(content); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return "", fmt.Errorf("failed to write %s: %v", tmp.Name(), err)
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return "", fmt.Errorf("failed to sync %s: %v", tmp.Name(), err)
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return "", fmt.Errorf("failed to close %s: %v", tmp.Name(), err)
+	}
+	return tmp.Name(), nil
+}
+
+// polls the dataset path and reloads whenever a file is added, removed or changed - runs in the background
+func (dr *DatasetReloader) WatchPath(path string, interval time.Duration) {
+	dr.reloadMutex.Lock()
+	dr.path = path
+	dr.fingerprint = datasetFingerprint(path)
+	dr.reloadMutex.Unlock()
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for range ticker.C {
+			if !dr.hasChanged() {
+				continue
+			}
+			// giving the writer a moment to finish before parsing a half-copied file
+			if !dr.waitUntilStable(interval / 4) {
+				continue
+			}
+			log.Printf("Dataset %s changed on disk, reloading...", path)
+			if err := dr.Reload(); err != nil {
+				log.Printf("Warning: Could not reload flight data: %v", err)
+			}
+		}
+	}()
+	log.Printf("Watching %s for changes every %s", path, interval)
+}
+
+// reloads the dataset whenever the process receives SIGHUP
+func (dr *DatasetReloader) WatchSignals() {
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGHUP)
+
+	go func() {
+		for range signals {
+			log.Println("Received SIGHUP, reloading flight data...")
+			if err := dr.Reload(); err != nil {
+				log.Printf("Warning: Could not reload flight data: %v", err)
+			}
+		}
+	}()
+}
+
+// checks if the watched files look different from the ones we loaded last
+func (dr *DatasetReloader) hasChanged() bool {
+	dr.reloadMutex.Lock()
+	defer dr.reloadMutex.Unlock()
+
+	fingerprint := datasetFingerprint(dr.path)
+	return fingerprint != "" && fingerprint != dr.fingerprint
+}
+
+// returns true once the files stop changing between two looks taken settle apart
+func (dr *DatasetReloader) waitUntilStable(settle time.Duration) bool {
+	before := datasetFingerprint(dr.path)
+	time.Sleep(settle)
+	after := datasetFingerprint(dr.path)
+	return before != "" && before == after
+}
@@ -0,0 +1,94 @@
+package services
+
+import (
+	"fmt"
+	"strings"
+
+	"flight-dashboard-backend/models"
+)
+
+// what to do with rows that share a dedup key
+const (
+	DedupKeepFirst    = "keep_first"    // keep the first row seen, drop the rest
+	DedupKeepCheapest = "keep_cheapest" // keep the row with the lowest price, drop the rest
+	DedupFlag         = "flag"          // keep every row but mark the repeats as duplicates
+	DedupOff          = "off"           // keep everything as is
+)
+
+// which fields make two rows the same flight and what to do about it - set under "dedup" in column_schema.json
+type DedupConfig struct {
+	Key      []string `json:"key"`
+	Strategy string   `json:"strategy"`
+}
+
+// identical rows in public datasets usually agree on all of these
+// price and class are part of it - a different fare or cabin on the same flight is a separate row, not a repeat
+func defaultDedupConfig() DedupConfig {
+	return DedupConfig{
+		Key:      []string{"airline", "flight_date", "source", "destination", "departure_time", "price", "flight_class", "route"},
+		Strategy: DedupKeepFirst,
+	}
+}
+
+// checks the key only uses flight fields the schema knows and the strategy exists
+func (dc DedupConfig) validate() error {
+	switch dc.Strategy {
+	case DedupKeepFirst, DedupKeepCheapest, DedupFlag, DedupOff:
+	default:
+		return fmt.Errorf("unknown dedup strategy %q", dc.Strategy)
+	}
+	if dc.Strategy != DedupOff && len(dc.Key) == 0 {
+		return fmt.Errorf("dedup key can't be empty")
+	}
+	for _, field := range dc.Key {
+		if !isSchemaField(field) {
+			return fmt.Errorf("unknown dedup key field %q", field)
+		}
+	}
+	return nil
+}
+
+// builds the dedup key of a flight - case and surrounding spaces don't matter
+func (dc DedupConfig) keyFor(flight *models.Flight) string {
+	parts := make([]string, len(dc.Key))
+	for i, field := range dc.Key {
+		parts[i] = strings.ToLower(strings.TrimSpace(flightFieldValue(flight, field)))
+	}
+	return strings.Join(parts, "\x1f")
+}
+
+// applies the dedup strategy to a merged dataset
+// returns the flights to keep and how many repeats were removed or flagged
+func deduplicateFlights(flights []models.Flight, config DedupConfig) ([]models.Flight, int) {
+	if config.Strategy == DedupOff || len(flights) == 0 {
+		return flights, 0
+	}
+
+	kept := make([]models.Flight, 0, len(flights))
+	seen := make(map[string]int, len(flights)) // key -> index in kept
+	duplicates := 0
+
+	for _, flight := range flights {
+		key := config.keyFor(&flight)
+		idx, exists := seen[key]
+		if !exists {
+			seen[key] = len(kept)
+			kept = append(kept, flight)
+			continue
+		}
+
+		duplicates++
+		switch config.Strategy {
+		case DedupFlag:
+			flight.Duplicate = true
+			kept = append(kept, flight)
+		case DedupKeepCheapest:
+			// a price of 0 means we couldn't read it, so any real price beats it
+			current := kept[idx].Price
+			if flight.Price > 0 && (current <= 0 || flight.Price < current) {
+				kept[idx] = flight
+			}
+		}
+	}
+	return kept, duplicates
+}
@@ -0,0 +1,117 @@
+package services
+
+import (
+	"strings"
+
+	"flight-dashboard-backend/models"
+)
+
+// flights of one district, counted the same way as for its state - once per flight
+type DistrictAggregation struct {
+	District             string         `json:"district"` // as in the state's topojson
+	State                string         `json:"state"`
+	TotalFlights         int            `json:"total_flights"`
+	IncomingFlights      int            `json:"incoming_flights"`
+	OutgoingFlights      int            `json:"outgoing_flights"`
+	IntraDistrictFlights int            `json:"intra_district_flights"` // both ends in the district, counted once
+	UniqueRoutes         int            `json:"unique_routes"`
+	Airlines             map[string]int `json:"airlines"`
+	RouteDetails         map[string]int `json:"route_details"`
+}
+
+func newDistrictAggregation(district, state string) *DistrictAggregation {
+	return &DistrictAggregation{
+		District:     district,
+		State:        state,
+		Airlines:     make(map[string]int),
+		RouteDetails: make(map[string]int),
+	}
+}
+
+// the districts of one state, with the flights that couldn't be placed in any of them
+type StateDistricts struct {
+	State             string                 `json:"state"`
+	Districts         []*DistrictAggregation `json:"districts"`          // sorted by name, zeros for districts without flights
+	UnassignedFlights int                    `json:"unassigned_flights"` // flights of the state with an end in it that has no district
+}
+
+// builds district aggregations for the given flights without touching the stored ones
+// flights whose city has no district only count towards the state, and per state as unassigned
+func (sa *StateAggregator) buildDistrictAggregations(flights []models.Flight) (map[string]map[string]*DistrictAggregation, map[string]int) {
+	districts := make(map[string]map[string]*DistrictAggregation)
+	unassigned := make(map[string]int)
+	get := func(district, state string) *DistrictAggregation {
+		if districts[state] == nil {
+			districts[state] = make(map[string]*DistrictAggregation)
+		}
+		if districts[state][district] == nil {
+			districts[state][district] = newDistrictAggregation(district, state)
+		}
+		return districts[state][district]
+	}
+
+	for _, flight := range flights {
+		routeKey := strings.ToLower(flight.Source + "->" + flight.Destination)
+		sourceDistrict, sourceState, sourceOk := sa.mapper.GetDistrictForCity(flight.Source)
+		destDistrict, destState, destOk := sa.mapper.GetDistrictForCity(flight.Destination)
+		intraDistrict := sourceOk && destOk && sourceDistrict == destDistrict && sourceState == destState
+
+		// once per state, even when neither end of an intra-state flight has a district
+		if !sourceOk && sourceState != "" {
+			unassigned[sourceState]++
+		}
+		if !destOk && destState != "" && (destState != sourceState || sourceOk) {
+			unassigned[destState]++
+		}
+
+		if sourceOk {
+			agg := get(sourceDistrict, sourceState)
+			if intraDistrict {
+				agg.IntraDistrictFlights++
+			} else {
+				agg.OutgoingFlights++
+			}
+			agg.TotalFlights++
+			agg.Airlines[flight.Airline]++
+			agg.RouteDetails[routeKey]++
+		}
+		if destOk && !intraDistrict {
+			agg := get(destDistrict, destState)
+			agg.IncomingFlights++
+			agg.TotalFlights++
+			agg.Airlines[flight.Airline]++
+			agg.RouteDetails[routeKey]++
+		}
+	}
+
+	for _, byDistrict := range districts {
+		for _, agg := range byDistrict {
+			agg.UniqueRoutes = len(agg.RouteDetails)
+		}
+	}
+	return districts, unassigned
+}
+
+// returns the aggregation of every district of a state - the bool is false when the state isn't known
+func (sa *StateAggregator) GetDistrictAggregationsForState(stateName string) (StateDistricts, bool) {
+	state, ok := GetStateRegistry().Resolve(stateName)
+	if !ok {
+		return StateDistricts{}, false
+	}
+
+	sa.mutex.RLock()
+	byDistrict := sa.districts[state.Name]
+	unassigned := sa.undistricted[state.Name]
+	sa.mutex.RUnlock()
+
+	names := sa.mapper.GetDistrictsForState(state.Name)
+	result := make([]*DistrictAggregation, 0, len(names))
+	for _, name := range names {
+		if agg, exists := byDistrict[name]; exists {
+			result = append(result, agg)
+		} else {
+			result = append(result, newDistrictAggregation(name, state.Name))
+		}
+	}
+	return StateDistricts{State: state.Name, Districts: result, UnassignedFlights: unassigned}, true
+}
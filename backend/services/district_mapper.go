@@ -0,0 +1,109 @@
+package services
+
+import (
+	"encoding/json"
+	"log"
+	"os"
+	"sort"
+	"strings"
+)
+
+// state -> district -> cities, district names as in the frontend topojson (the "district" property)
+const districtMapPath = "data/district_map.json"
+
+// where a city sits one level below its state
+type cityDistrict struct {
+	State    string // canonical state name
+	District string
+}
+
+// loads the district level of the mapping - read once at startup, so lookups need no lock
+// without the file cities still resolve to states, they just have no district
+func (csm *CityStateMapper) loadDistrictMap() {
+	csm.cityToDistrict = make(map[string]cityDistrict)
+	csm.districtsByState = make(map[string][]string)
+
+	data, err := os.ReadFile(districtMapPath)
+	if err != nil {
+		log.Println("Could not load district map from JSON file, districts won't be resolved:", err)
+		return
+	}
+	var rawMap map[string]map[string][]string
+	if err := json.Unmarshal(data, &rawMap); err != nil {
+		log.Printf("Error parsing district map JSON: %v, districts won't be resolved", err)
+		return
+	}
+
+	// states and districts are walked in sorted order so a city listed twice always lands in the same one
+	stateNames := make([]string, 0, len(rawMap))
+	for stateName := range rawMap {
+		stateNames = append(stateNames, stateName)
+	}
+	sort.Strings(stateNames)
+
+	for _, stateName := range stateNames {
+		state, ok := GetStateRegistry().Resolve(stateName)
+		if !ok {
+			log.Printf("Warning: district map lists unknown state %q, skipping it", stateName)
+			continue
+		}
+		districts := rawMap[stateName]
+		rawNames := make([]string, 0, len(districts))
+		for district := range districts {
+			rawNames = append(rawNames, district)
+		}
+		sort.Strings(rawNames)
+
+		names := make([]string, 0, len(districts))
+		for _, rawName := range rawNames {
+			district := strings.TrimSpace(rawName)
+			names = append(names, district)
+			for _, city := range districts[rawName] {
+				city = strings.ToLower(strings.TrimSpace(city))
+				if existing, taken := csm.cityToDistrict[city]; taken {
+					log.Printf("Warning: %q is in districts %s (%s) and %s (%s), keeping %s - the first in sorted order",
+						city, existing.District, existing.State, district, state.Name, existing.District)
+					continue
+				}
+				csm.cityToDistrict[city] = cityDistrict{State: state.Name, District: district}
+			}
+		}
+		sort.Strings(names)
+		csm.districtsByState[state.Name] = names
+	}
+
+	log.Printf("Loaded district mapping for %d cities in %d states", len(csm.cityToDistrict), len(csm.districtsByState))
+}
+
+// returns the district and canonical state of a city - the city is resolved the same way as for GetStateForCity
+// cities without an entry fall back to the district of the same name, or the only district of their state
+func (csm *CityStateMapper) GetDistrictForCity(city string) (string, string, bool) {
+	match, ok := csm.ResolveCity(city)
+	if !ok {
+		return "", "", false
+	}
+	state := GetStateRegistry().CanonicalName(match.State)
+
+	// an entry only counts while the city is still mapped to that state - it may have moved since
+	if entry, exists := csm.cityToDistrict[match.City]; exists && entry.State == state {
+		return entry.District, state, true
+	}
+	districts := csm.districtsByState[state]
+	for _, district := range districts {
+		if strings.EqualFold(district, match.City) {
+			return district, state, true
+		}
+	}
+	if len(districts) == 1 {
+		return districts[0], state, true
+	}
+	return "", state, false
+}
+
+// returns every district of a state, sorted - the state can be given any way the state registry understands
+func (csm *CityStateMapper) GetDistrictsForState(state string) []string {
+	districts := csm.districtsByState[GetStateRegistry().CanonicalName(state)]
+	result := make([]string, len(districts))
+	copy(result, districts)
+	return result
+}
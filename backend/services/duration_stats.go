@@ -0,0 +1,222 @@
+package services
+
+import (
+	"sort"
+
+	"flight-dashboard-backend/models"
+)
+
+// how the route durations can be ordered
+const (
+	RouteSortFlights    = "flights"    // most flights first
+	RouteSortEfficiency = "efficiency" // slowest compared to a direct flight first
+)
+
+// duration distribution in hours of one state, over the flights leaving it and arriving in it
+type StateDurations struct {
+	State         string            `json:"state"`
+	AsOrigin      DistributionStats `json:"as_origin"`
+	AsDestination DistributionStats `json:"as_destination"`
+	Efficiency    DistributionStats `json:"efficiency"` // actual / expected block time of the flights starting or ending in the state, once each
+}
+
+// duration distribution in hours of one city pair, with the distance between the two cities
+// distance, expected hours and efficiency are 0 when a city has no coordinates in the airport registry
+type RouteDurations struct {
+	Source        string            `json:"source"`
+	Destination   string            `json:"destination"`
+	Durations     DistributionStats `json:"durations"`
+	DistanceKm    float64           `json:"distance_km"`    // great-circle distance
+	ExpectedHours float64           `json:"expected_hours"` // block time of a direct flight over the distance
+	Efficiency    float64           `json:"efficiency"`     // median duration / expected hours - 1 is about a direct flight, 2 takes twice as long
+}
+
+// every duration distribution of one set of flights - only flights with a duration count
+type DurationIndex struct {
+	Overall DistributionStats
+	States  map[string]StateDurations // by canonical state
+	Routes  map[string]RouteDurations // by "source->destination"
+}
+
+// builds the duration distributions for the given flights without touching the stored ones
+func (sa *StateAggregator) buildDurationIndex(flights []models.Flight) *DurationIndex {
+	var overall []float64
+	origin := make(map[string][]float64)
+	destination := make(map[string][]float64)
+	efficiency := make(map[string][]float64)
+	routes := make(map[string][]float64)
+	routeInfo := make(map[string]RouteDurations)
+
+	// cities repeat on every row, so each one is located once
+	type location struct {
+		airport models.Airport
+		ok      bool
+	}
+	located := make(map[string]location)
+	locate := func(city string) (models.Airport, bool) {
+		if loc, seen := located[city]; seen {
+			return loc.airport, loc.ok
+		}
+		airport, ok := sa.mapper.locateCity(city)
+		located[city] = location{airport, ok}
+		return airport, ok
+	}
+
+	for i := range flights {
+		flight := &flights[i]
+		hours := flightHours(flight)
+		if hours <= 0 {
+			continue
+		}
+		overall = append(overall, hours)
+
+		source, dest := sa.mapper.canonicalCity(flight.Source), sa.mapper.canonicalCity(flight.Destination)
+		key := source + "->" + dest
+		routes[key] = append(routes[key], hours)
+		info, seen := routeInfo[key]
+		if !seen {
+			info = RouteDurations{Source: source, Destination: dest}
+			sourceAirport, sourceLocated := locate(flight.Source)
+			destAirport, destLocated := locate(flight.Destination)
+			if sourceLocated && destLocated {
+				info.DistanceKm = greatCircleKm(sourceAirport.Latitude, sourceAirport.Longitude, destAirport.Latitude, destAirport.Longitude)
+			}
+			if info.DistanceKm > 0 {
+				info.ExpectedHours = expectedBlockTime(info.DistanceKm)
+			}
+			routeInfo[key] = info
+		}
+
+		sourceState, sourceOk, destState, destOk := sa.mapper.resolveFlightStates(flight)
+		if sourceOk {
+			origin[sourceState] = append(origin[sourceState], hours)
+		}
+		if destOk {
+			destination[destState] = append(destination[destState], hours)
+		}
+		if info.ExpectedHours > 0 {
+			ratio := hours / info.ExpectedHours
+			if sourceOk {
+				efficiency[sourceState] = append(efficiency[sourceState], ratio)
+			}
+			if destOk && !(sourceOk && sourceState == destState) {
+				efficiency[destState] = append(efficiency[destState], ratio)
+			}
+		}
+	}
+
+	index := &DurationIndex{
+		Overall: distributionStats(overall),
+		States:  make(map[string]StateDurations),
+		Routes:  make(map[string]RouteDurations, len(routes)),
+	}
+	for state, hours := range origin {
+		durations := index.States[state]
+		durations.State = state
+		durations.AsOrigin = distributionStats(hours)
+		index.States[state] = durations
+	}
+	for state, hours := range destination {
+		durations := index.States[state]
+		durations.State = state
+		durations.AsDestination = distributionStats(hours)
+		index.States[state] = durations
+	}
+	for state, ratios := range efficiency {
+		durations := index.States[state]
+		durations.Efficiency = distributionStats(ratios)
+		index.States[state] = durations
+	}
+	for key, hours := range routes {
+		info := routeInfo[key]
+		info.Durations = distributionStats(hours)
+		if info.ExpectedHours > 0 {
+			info.Efficiency = roundTo(info.Durations.Median/info.ExpectedHours, 2)
+		}
+		info.DistanceKm = roundTo(info.DistanceKm, 1)
+		info.ExpectedHours = roundTo(info.ExpectedHours, 2)
+		index.Routes[key] = info
+	}
+	return index
+}
+
+// returns the duration distribution over every flight with a duration
+func (sa *StateAggregator) GetOverallDurations() DistributionStats {
+	sa.mutex.RLock()
+	defer sa.mutex.RUnlock()
+	if sa.durations == nil {
+		return DistributionStats{}
+	}
+	return sa.durations.Overall
+}
+
+// returns the durations of one state by any name the state registry knows, and a bool to check if the state exists
+func (sa *StateAggregator) GetDurationsForState(stateName string) (StateDurations, bool) {
+	state, ok := GetStateRegistry().Resolve(stateName)
+	if !ok {
+		return StateDurations{}, false
+	}
+
+	sa.mutex.RLock()
+	defer sa.mutex.RUnlock()
+	if sa.durations != nil {
+		if durations, exists := sa.durations.States[state.Name]; exists {
+			return durations, true
+		}
+	}
+	return StateDurations{State: state.Name}, true
+}
+
+// returns the durations of every state with timed flights, sorted by state name
+func (sa *StateAggregator) GetAllStateDurations() []StateDurations {
+	sa.mutex.RLock()
+	defer sa.mutex.RUnlock()
+
+	result := []StateDurations{}
+	if sa.durations != nil {
+		for _, durations := range sa.durations.States {
+			result = append(result, durations)
+		}
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].State < result[j].State
+	})
+	return result
+}
+
+// returns the durations of every city pair, ordered by RouteSortFlights or RouteSortEfficiency
+// source and destination narrow it down to routes from or to a city, under any name the city mapping resolves
+func (sa *StateAggregator) GetRouteDurations(source, destination, sortBy string) []RouteDurations {
+	if source != "" {
+		source = sa.mapper.canonicalCity(source)
+	}
+	if destination != "" {
+		destination = sa.mapper.canonicalCity(destination)
+	}
+
+	sa.mutex.RLock()
+	result := []RouteDurations{}
+	if sa.durations != nil {
+		for _, durations := range sa.durations.Routes {
+			if (source == "" || durations.Source == source) && (destination == "" || durations.Destination == destination) {
+				result = append(result, durations)
+			}
+		}
+	}
+	sa.mutex.RUnlock()
+
+	sort.Slice(result, func(i, j int) bool {
+		a, b := result[i], result[j]
+		if sortBy == RouteSortEfficiency && a.Efficiency != b.Efficiency {
+			return a.Efficiency > b.Efficiency
+		}
+		if a.Durations.Count != b.Durations.Count {
+			return a.Durations.Count > b.Durations.Count
+		}
+		if a.Source != b.Source {
+			return a.Source < b.Source
+		}
+		return a.Destination < b.Destination
+	})
+	return result
+}
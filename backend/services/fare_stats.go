@@ -0,0 +1,189 @@
+package services
+
+import (
+	"sort"
+
+	"flight-dashboard-backend/models"
+)
+
+// fare distribution of one state, over the flights leaving it and the flights arriving in it
+// an intra-state flight is part of both
+type StateFares struct {
+	State         string            `json:"state"`
+	AsOrigin      DistributionStats `json:"as_origin"`
+	AsDestination DistributionStats `json:"as_destination"`
+}
+
+// fare distribution of one city pair, cities as the city mapping resolves them
+type RouteFares struct {
+	Source      string            `json:"source"`
+	Destination string            `json:"destination"`
+	Fares       DistributionStats `json:"fares"`
+}
+
+// fare distribution of one airline
+type AirlineFares struct {
+	Airline string            `json:"airline"`
+	Fares   DistributionStats `json:"fares"`
+}
+
+// every fare distribution of one set of flights - only flights with a price count
+type FareIndex struct {
+	Overall  DistributionStats
+	States   map[string]StateFares   // by canonical state
+	Routes   map[string]RouteFares   // by "source->destination"
+	Airlines map[string]AirlineFares // by airline as written in the dataset
+}
+
+// builds the fare distributions for the given flights without touching the stored ones
+func (sa *StateAggregator) buildFareIndex(flights []models.Flight) *FareIndex {
+	var overall []float64
+	origin := make(map[string][]float64)
+	destination := make(map[string][]float64)
+	routes := make(map[string][]float64)
+	airlines := make(map[string][]float64)
+	routeCities := make(map[string][2]string)
+
+	for i := range flights {
+		flight := &flights[i]
+		if flight.Price <= 0 {
+			continue
+		}
+		overall = append(overall, flight.Price)
+		airlines[flight.Airline] = append(airlines[flight.Airline], flight.Price)
+
+		sourceState, sourceOk, destState, destOk := sa.mapper.resolveFlightStates(flight)
+		if sourceOk {
+			origin[sourceState] = append(origin[sourceState], flight.Price)
+		}
+		if destOk {
+			destination[destState] = append(destination[destState], flight.Price)
+		}
+
+		source, dest := sa.mapper.canonicalCity(flight.Source), sa.mapper.canonicalCity(flight.Destination)
+		key := source + "->" + dest
+		routeCities[key] = [2]string{source, dest}
+		routes[key] = append(routes[key], flight.Price)
+	}
+
+	index := &FareIndex{
+		Overall:  distributionStats(overall),
+		States:   make(map[string]StateFares),
+		Routes:   make(map[string]RouteFares, len(routes)),
+		Airlines: make(map[string]AirlineFares, len(airlines)),
+	}
+	for state, prices := range origin {
+		fares := index.States[state]
+		fares.State = state
+		fares.AsOrigin = distributionStats(prices)
+		index.States[state] = fares
+	}
+	for state, prices := range destination {
+		fares := index.States[state]
+		fares.State = state
+		fares.AsDestination = distributionStats(prices)
+		index.States[state] = fares
+	}
+	for key, prices := range routes {
+		cities := routeCities[key]
+		index.Routes[key] = RouteFares{Source: cities[0], Destination: cities[1], Fares: distributionStats(prices)}
+	}
+	for airline, prices := range airlines {
+		index.Airlines[airline] = AirlineFares{Airline: airline, Fares: distributionStats(prices)}
+	}
+	return index
+}
+
+// returns the fare distribution over every flight with a price
+func (sa *StateAggregator) GetOverallFares() DistributionStats {
+	sa.mutex.RLock()
+	defer sa.mutex.RUnlock()
+	if sa.fares == nil {
+		return DistributionStats{}
+	}
+	return sa.fares.Overall
+}
+
+// returns the fares of one state by any name the state registry knows, and a bool to check if the state exists
+func (sa *StateAggregator) GetFaresForState(stateName string) (StateFares, bool) {
+	state, ok := GetStateRegistry().Resolve(stateName)
+	if !ok {
+		return StateFares{}, false
+	}
+
+	sa.mutex.RLock()
+	defer sa.mutex.RUnlock()
+	if sa.fares != nil {
+		if fares, exists := sa.fares.States[state.Name]; exists {
+			return fares, true
+		}
+	}
+	return StateFares{State: state.Name}, true
+}
+
+// returns the fares of every state with priced flights, sorted by state name
+func (sa *StateAggregator) GetAllStateFares() []StateFares {
+	sa.mutex.RLock()
+	defer sa.mutex.RUnlock()
+
+	result := []StateFares{}
+	if sa.fares != nil {
+		for _, fares := range sa.fares.States {
+			result = append(result, fares)
+		}
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].State < result[j].State
+	})
+	return result
+}
+
+// returns the fares of every city pair, most priced flights first
+// source and destination narrow it down to routes from or to a city, under any name the city mapping resolves
+func (sa *StateAggregator) GetRouteFares(source, destination string) []RouteFares {
+	if source != "" {
+		source = sa.mapper.canonicalCity(source)
+	}
+	if destination != "" {
+		destination = sa.mapper.canonicalCity(destination)
+	}
+
+	sa.mutex.RLock()
+	result := []RouteFares{}
+	if sa.fares != nil {
+		for _, fares := range sa.fares.Routes {
+			if (source == "" || fares.Source == source) && (destination == "" || fares.Destination == destination) {
+				result = append(result, fares)
+			}
+		}
+	}
+	sa.mutex.RUnlock()
+
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Fares.Count != result[j].Fares.Count {
+			return result[i].Fares.Count > result[j].Fares.Count
+		}
+		if result[i].Source != result[j].Source {
+			return result[i].Source < result[j].Source
+		}
+		return result[i].Destination < result[j].Destination
+	})
+	return result
+}
+
+// returns the fares of every airline, sorted by airline name
+func (sa *StateAggregator) GetAirlineFares() []AirlineFares {
+	sa.mutex.RLock()
+	defer sa.mutex.RUnlock()
+
+	result := []AirlineFares{}
+	if sa.fares != nil {
+		for _, fares := range sa.fares.Airlines {
+			result = append(result, fares)
+		}
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Airline < result[j].Airline
+	})
+	return result
+}
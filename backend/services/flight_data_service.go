@@ -1,7 +1,7 @@
 package services
 
 import (
-	"encoding/csv"
+	"errors"
 	"fmt"
 	"io"
 	"log"
@@ -9,14 +9,17 @@ import (
 	"strconv"
 	"strings"
 	"sync"
+	"time"
 
 	"flight-dashboard-backend/models"
 )
 
-// this service handles loading and accessing the flight data from CSV
+// this service handles loading and accessing the flight data
 type FlightDataService struct {
-	flights []models.Flight
-	mutex   sync.RWMutex
+	flights  []models.Flight
+	dataPath string          // file, directory or glob the current flights were loaded from
+	report   IngestionReport // summary of the load that produced the current flights
+	mutex    sync.RWMutex
 }
 
 // global instance so we can access the flight data anywhere in the app
@@ -31,152 +34,99 @@ func GetFlightDataService() *FlightDataService {
 	return flightDataService
 }
 
-// loads flight data from CSV file into memory - this is called when the app starts
-func (fds *FlightDataService) LoadFlightDataFromCSV(filePath string) error {
+// returned (wrapped) when a dataset is readable but can't be used - bad header, no valid rows etc.
+var ErrInvalidDataset = errors.New("invalid dataset")
+
+// loads flight data into memory - this is called when the app starts
+// the path can be a file, a directory or a glob; every file is loaded and merged into one dataset
+// the format (csv, tsv, ndjson, parquet, optionally gzip/zstd/zip compressed) is picked per file
+func (fds *FlightDataService) LoadFlightData(path string) error {
+	// parsing happens outside the lock so readers keep seeing the old data meanwhile
+	flights, report, err := readFlightsFromPath(path)
+	if err != nil {
+		return err
+	}
+
 	fds.mutex.Lock()
-	defer fds.mutex.Unlock()
+	fds.flights = flights
+	fds.dataPath = path
+	fds.report = report
+	fds.mutex.Unlock()
+
+	log.Printf("Successfully loaded %d flight records from %d file(s) in %s (%d rejected)",
+		report.AcceptedRows, len(report.Files), path, report.RejectedRows)
+	return nil
+}
 
+// reads and parses every record of a dataset file without touching the loaded data
+func readFlightsFromFile(filePath string) ([]models.Flight, IngestionReport, error) {
 	file, err := os.Open(filePath)
 	if err != nil {
-		return fmt.Errorf("failed to open file %s: %v", filePath, err)
+		return nil, IngestionReport{}, fmt.Errorf("failed to open file %s: %v", filePath, err)
 	}
 	defer file.Close()
 
-	reader := csv.NewReader(file)
+	return readFlightsFromReader(file, filePath)
+}
+
+// parses flight data from any reader - the name picks the format when the content doesn't give it away
+func readFlightsFromReader(r io.Reader, source string) ([]models.Flight, IngestionReport, error) {
+	report := IngestionReport{Source: source, Issues: []IngestionIssue{}}
 
-	// Read header to understand column order
-	header, err := reader.Read()
+	records, cleanup, err := openRecordReader(source, r)
 	if err != nil {
-		return fmt.Errorf("failed to read header: %v", err)
+		return nil, report, err
 	}
+	defer cleanup()
 
-	// Map header columns to their indices
-	columnIndices := make(map[string]int)
-	for i, col := range header {
-		columnIndices[strings.TrimSpace(strings.ToLower(col))] = i
+	// Map header columns to the flight fields through the column schema
+	binding, err := GetColumnSchema().bindHeader(records.Header())
+	if err != nil {
+		return nil, report, err
 	}
 
 	var flights []models.Flight
 
 	// Read each record
 	for {
-		record, err := reader.Read()
+		record, line, err := records.Read()
 		if err == io.EOF {
 			break
 		}
-		if err != nil {
-			log.Printf("Error reading CSV record: %v", err)
+		var rowErr *RowDecodeError
+		if errors.As(err, &rowErr) {
+			report.RejectedRows++
+			report.addIssues(IngestionIssue{
+				Line:     rowErr.Line,
+				Column:   "*",
+				RawValue: rowErr.Raw,
+				Action:   IssueDropped,
+				Reason:   rowErr.Error(),
+			})
 			continue 
 		}
-
-		flight, err := parseFlightRecord(record, columnIndices)
 		if err != nil {
-			log.Printf("Skipping invalid record: %v, Record: %v", err, record)
-			continue 
-		}
-
-		flights = append(flights, flight)
-	}
-
-	fds.flights = flights
-	log.Printf("Successfully loaded %d flight records from %s", len(flights), filePath)
-	return nil
-}
-
-// converts a CSV record to a Flight struct 
-func parseFlightRecord(record []string, indices map[string]int) (models.Flight, error) {
-	var flight models.Flight
-
-	// Helper function to get field value
-	getField := func(fieldName string) string {
-		if idx, exists := indices[fieldName]; exists && idx < len(record) {
-			return strings.TrimSpace(record[idx])
+			return nil, report, fmt.Errorf("failed to read %s: %v", source, err)
 		}
-		return ""
-	}
 
-	// Map fields 
-	flight.Airline = getField("airline")
-	flight.FlightDate = getField("date_of_journey") 
-	if flight.FlightDate == "" {
-		flight.FlightDate = getField("flight_date") 
-	}
-	if flight.FlightDate == "" {
-		flight.FlightDate = getField("flight date") 
-	}
-
-	flight.Source = getField("source")
-	if flight.Source == "" {
-		flight.Source = getField("from_city") 
-	}
-	if flight.Source == "" {
-		flight.Source = getField("from") 
-	}
-
-	flight.Destination = getField("destination")
-	if flight.Destination == "" {
-		flight.Destination = getField("to_city") 
-	}
-	if flight.Destination == "" {
-		flight.Destination = getField("to") 
-	}
-
-	flight.FlightClass = getField("class")
-	if flight.FlightClass == "" {
-		flight.FlightClass = getField("flight_class") 
-	}
-
-	// flight duration
-	durationStr := getField("duration")
-	if durationStr != "" {
-		flight.Duration = parseDuration(durationStr)
-	}
-
-	// Parse price
-	priceStr := getField("price")
-	if priceStr != "" {
-		cleanPriceStr := strings.ReplaceAll(priceStr, ",", "")
-		cleanPriceStr = cleanNonNumeric(cleanPriceStr)
-		price, err := strconv.ParseFloat(cleanPriceStr, 64)
+		flight, issues, err := parseFlightRecord(record, binding, line)
+		report.addIssues(issues...)
 		if err != nil {
-			price = 0 // default to 0 if parsing fails
+			report.RejectedRows++
+			continue 
 		}
-		flight.Price = price
-	}
+		report.countRowIssues(issues)
 
-	flight.DepartureTime = getField("departure_time")
-	if flight.DepartureTime == "" {
-		flight.DepartureTime = getField("dep_time") 
-	}
-	if flight.DepartureTime == "" {
-		flight.DepartureTime = getField("dep_time") 
-	}
-
-	flight.ArrivalTime = getField("arrival_time")
-	if flight.ArrivalTime == "" {
-		flight.ArrivalTime = getField("arrival_time") 
-	}
-	if flight.ArrivalTime == "" {
-		flight.ArrivalTime = getField("arr_time") 
-	}
-
-	// parse stops.....
-	stopsStr := getField("stops")
-	if stopsStr != "" {
-		cleanStopsStr := cleanNonNumeric(stopsStr)
-		stops, err := strconv.Atoi(cleanStopsStr)
-		if err != nil {
-			stops = 0 // default to 0 if parsing fails
-		}
-		flight.Stops = stops
+		flights = append(flights, flight)
 	}
 
-	flight.AdditionalInfo = getField("additional_info")
-	if flight.AdditionalInfo == "" {
-		flight.AdditionalInfo = getField("info") 
+	report.AcceptedRows = len(flights)
+	report.LoadedAt = time.Now()
+	if report.RejectedRows > 0 || report.CoercedRows > 0 || report.FlaggedRows > 0 {
+		log.Printf("Ingestion of %s: %d rows dropped, %d rows coerced, %d rows flagged - see /api/ingestion/report",
+			source, report.RejectedRows, report.CoercedRows, report.FlaggedRows)
 	}
-
-	return flight, nil
+	return flights, report, nil
 }
 
 // returns all the loaded flight records
@@ -197,25 +147,40 @@ func (fds *FlightDataService) GetFlightCount() int {
 	return len(fds.flights)
 }
 
+// returns the path (file, directory or glob) of the currently loaded dataset
+func (fds *FlightDataService) GetDataPath() string {
+	fds.mutex.RLock()
+	defer fds.mutex.RUnlock()
+	return fds.dataPath
+}
+
+// returns the summary of the load behind the current flights
+func (fds *FlightDataService) GetIngestionReport() IngestionReport {
+	fds.mutex.RLock()
+	defer fds.mutex.RUnlock()
+	return fds.report
+}
+
 // gets the state for a given city using the city state mapper
 func (fds *FlightDataService) GetStateForCity(city string) (string, bool) {
 	mapper := GetCityStateMapper() // Use the new city state mapper
 	return mapper.GetStateForCity(city)
 }
 
-// counts flights for a specific state - checks both source and destination
+// counts flights for a specific state - distinct flights starting or ending in it, the same as the aggregator's TotalFlights
+// an intra-state flight counts once, and the cities are resolved exactly the way the aggregator resolves them
 func (fds *FlightDataService) GetFlightCountByState(state string) int {
 	count := 0
-	state = strings.ToLower(state)
+	mapper := GetCityStateMapper()
+	state = GetStateRegistry().CanonicalName(state)
 
 	fds.mutex.RLock()
 	defer fds.mutex.RUnlock()
 
-	for _, flight := range fds.flights {
+	for i := range fds.flights {
 		// checking if source or destination is in the given state
-		if sourceState, ok := fds.GetStateForCity(flight.Source); ok && strings.ToLower(sourceState) == state {
-			count++
-		} else if destState, ok := fds.GetStateForCity(flight.Destination); ok && strings.ToLower(destState) == state {
+		sourceState, sourceOk, destState, destOk := mapper.resolveFlightStates(&fds.flights[i])
+		if (sourceOk && sourceState == state) || (destOk && destState == state) {
 			count++
 		}
 	}
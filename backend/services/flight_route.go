@@ -0,0 +1,90 @@
+package services
+
+import (
+	"fmt"
+	"strings"
+
+	"flight-dashboard-backend/models"
+)
+
+// separators seen between airport codes in Route columns - the last one is "→" read as Windows-1252
+var routeSeparators = []string{"→", "->", "â†’", ">"}
+
+// fills Legs from the raw Route and makes Stops agree with it
+// returns issues for routes that can't be read or disagree with the stops column
+func resolveFlightRoute(flight *models.Flight, stopsRaw string, line int) []IngestionIssue {
+	if flight.Route == "" {
+		return nil
+	}
+
+	legs, err := parseRoute(flight.Route)
+	if err != nil {
+		return []IngestionIssue{{Line: line, Column: "route", RawValue: flight.Route, Action: IssueCoerced, Reason: err.Error()}}
+	}
+	flight.Legs = legs
+
+	var issues []IngestionIssue
+	if stops := len(legs) - 1; stops != flight.Stops {
+		if stopsRaw != "" {
+			issues = append(issues, IngestionIssue{Line: line, Column: "stops", RawValue: stopsRaw, Action: IssueCoerced,
+				Reason: fmt.Sprintf("route %s has %d stops, using that", flight.Route, stops)})
+		}
+		flight.Stops = stops
+	}
+	return issues
+}
+
+// splits a route like "BLR → NAG → DEL" into legs BLR-NAG and NAG-DEL
+func parseRoute(route string) ([]models.FlightLeg, error) {
+	normalized := route
+	for _, separator := range routeSeparators {
+		normalized = strings.ReplaceAll(normalized, separator, "|")
+	}
+
+	var codes []string
+	for _, part := range strings.Split(normalized, "|") {
+		code := strings.ToUpper(strings.TrimSpace(part))
+		if !isAirportCode(code) {
+			return nil, fmt.Errorf("%q is not an airport code, legs left empty", part)
+		}
+		// some rows repeat an airport, e.g. "DEL → BOM → BOM → COK" - that's not an extra stop
+		if len(codes) > 0 && codes[len(codes)-1] == code {
+			continue
+		}
+		codes = append(codes, code)
+	}
+	if len(codes) < 2 {
+		return nil, fmt.Errorf("route needs at least two airports, legs left empty")
+	}
+
+	legs := make([]models.FlightLeg, 0, len(codes)-1)
+	for i := 1; i < len(codes); i++ {
+		legs = append(legs, models.FlightLeg{From: codes[i-1], To: codes[i]})
+	}
+	return legs, nil
+}
+
+// IATA airport codes are three letters
+func isAirportCode(code string) bool {
+	if len(code) != 3 {
+		return false
+	}
+	for _, r := range code {
+		if r < 'A' || r > 'Z' {
+			return false
+		}
+	}
+	return true
+}
+
+// the airports a flight stops at between its source and destination, in order
+func stopoverCodes(flight *models.Flight) []string {
+	if len(flight.Legs) < 2 {
+		return nil
+	}
+	codes := make([]string, 0, len(flight.Legs)-1)
+	for _, leg := range flight.Legs[1:] {
+		codes = append(codes, leg.From)
+	}
+	return codes
+}
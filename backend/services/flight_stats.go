@@ -0,0 +1,93 @@
+package services
+
+import (
+	"math"
+	"sort"
+	"strings"
+
+	"flight-dashboard-backend/models"
+)
+
+// narrows flights down by airline and class - empty fields match everything, case doesn't matter
+type FlightFilter struct {
+	Airline string `json:"airline,omitempty"`
+	Class   string `json:"class,omitempty"`
+}
+
+func (f FlightFilter) matches(flight *models.Flight) bool {
+	if f.Airline != "" && !strings.EqualFold(strings.TrimSpace(flight.Airline), strings.TrimSpace(f.Airline)) {
+		return false
+	}
+	if f.Class != "" && !strings.EqualFold(strings.TrimSpace(flight.FlightClass), strings.TrimSpace(f.Class)) {
+		return false
+	}
+	return true
+}
+
+// median of the values, 0 when there are none - sorts the slice in place
+func median(values []float64) float64 {
+	sort.Float64s(values)
+	return percentile(values, 50)
+}
+
+// summary of a distribution of values - prices, durations and the like
+type DistributionStats struct {
+	Count  int     `json:"count"`
+	Min    float64 `json:"min"`
+	Max    float64 `json:"max"`
+	Mean   float64 `json:"mean"`
+	Median float64 `json:"median"`
+	P10    float64 `json:"p10"`
+	P25    float64 `json:"p25"`
+	P75    float64 `json:"p75"`
+	P90    float64 `json:"p90"`
+	StdDev float64 `json:"stddev"` // population standard deviation
+}
+
+// computes the distribution of the values, all zeros when there are none - sorts the slice in place
+func distributionStats(values []float64) DistributionStats {
+	if len(values) == 0 {
+		return DistributionStats{}
+	}
+	sort.Float64s(values)
+
+	sum := 0.0
+	for _, value := range values {
+		sum += value
+	}
+	mean := sum / float64(len(values))
+	variance := 0.0
+	for _, value := range values {
+		variance += (value - mean) * (value - mean)
+	}
+	variance /= float64(len(values))
+
+	return DistributionStats{
+		Count:  len(values),
+		Min:    roundTo(values[0], 2),
+		Max:    roundTo(values[len(values)-1], 2),
+		Mean:   roundTo(mean, 2),
+		Median: roundTo(percentile(values, 50), 2),
+		P10:    roundTo(percentile(values, 10), 2),
+		P25:    roundTo(percentile(values, 25), 2),
+		P75:    roundTo(percentile(values, 75), 2),
+		P90:    roundTo(percentile(values, 90), 2),
+		StdDev: roundTo(math.Sqrt(variance), 2),
+	}
+}
+
+// the p-th percentile of sorted values, interpolating between the two closest ranks
+func percentile(sorted []float64, p float64) float64 {
+	if len(sorted) == 0 {
+		return 0
+	}
+	rank := p / 100 * float64(len(sorted)-1)
+	lower := int(math.Floor(rank))
+	upper := int(math.Ceil(rank))
+	return sorted[lower] + (sorted[upper]-sorted[lower])*(rank-float64(lower))
+}
+
+func roundTo(value float64, decimals int) float64 {
+	scale := math.Pow(10, float64(decimals))
+	return math.Round(value*scale) / scale
+}
@@ -0,0 +1,178 @@
+package services
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"flight-dashboard-backend/models"
+)
+
+// all flight times in the datasets are local Indian times
+var istLocation = time.FixedZone("IST", 5*60*60+30*60)
+
+// how far the computed block time may drift from the Duration column before we flag the row
+const blockTimeTolerance = 10 * time.Minute
+
+// clock layouts we accept for departure and arrival times
+var clockLayouts = []string{"15:04", "15:04:05", "3:04 PM", "3:04PM"}
+
+// day-part buckets some datasets use instead of clock times - not an error, just nothing to parse
+var dayParts = map[string]bool{
+	"early_morning": true, "morning": true, "afternoon": true,
+	"evening": true, "night": true, "late_night": true,
+}
+
+// fills FlightDay, Departure, Arrival and BlockTime from the raw string fields
+// returns issues for values that couldn't be parsed or don't add up with Duration
+func resolveFlightTimestamps(flight *models.Flight, line int) []IngestionIssue {
+	var issues []IngestionIssue
+	issue := func(action, column, raw, reason string) {
+		issues = append(issues, IngestionIssue{Line: line, Column: column, RawValue: raw, Action: action, Reason: reason})
+	}
+
+	// the date parser in the column schema already normalised the date, anything else was reported there
+	day, err := time.ParseInLocation("2006-01-02", flight.FlightDate, istLocation)
+	if err != nil {
+		return nil
+	}
+	flight.FlightDay = day
+
+	if flight.DepartureTime == "" {
+		return nil
+	}
+	depClock, ok := parseClock(flight.DepartureTime)
+	if !ok {
+		if !dayParts[strings.ToLower(flight.DepartureTime)] {
+			issue(IssueCoerced, "departure_time", flight.DepartureTime, "not a clock time, departure left empty")
+		}
+		return issues
+	}
+	flight.Departure = day.Add(depClock)
+
+	if flight.ArrivalTime == "" {
+		return issues
+	}
+	expected := time.Duration(flight.Duration * float64(time.Hour))
+	arrival, embedded, ok := parseArrival(flight.ArrivalTime, flight.Departure, expected)
+	if !ok {
+		if !dayParts[strings.ToLower(flight.ArrivalTime)] {
+			issue(IssueCoerced, "arrival_time", flight.ArrivalTime, "unrecognised arrival time, arrival left empty")
+		}
+		return issues
+	}
+
+	// the arrival clock of a flight abroad is in the other end's local time, which we don't know -
+	// reading it as IST would give a wrong block time and a false duration mismatch, so leave both empty
+	if flightLeavesIndia(flight) {
+		return issues
+	}
+
+	// some datasets carry an arrival date that's before the departure - roll the clock forward instead
+	if embedded && arrival.Before(flight.Departure) {
+		rolled := rollForward(flight.Departure, arrival, expected)
+		issue(IssueCoerced, "arrival_time", flight.ArrivalTime,
+			fmt.Sprintf("arrival date is before departure, using %s", rolled.Format("2006-01-02 15:04")))
+		arrival = rolled
+	}
+	flight.Arrival = arrival
+	flight.BlockTime = arrival.Sub(flight.Departure).Hours()
+
+	if flight.Duration > 0 {
+		drift := time.Duration(math.Abs(flight.BlockTime-flight.Duration) * float64(time.Hour))
+		if drift > blockTimeTolerance {
+			issue(IssueFlagged, "duration", strconv.FormatFloat(flight.Duration, 'f', 2, 64),
+				fmt.Sprintf("departure to arrival is %.2fh but duration says %.2fh", flight.BlockTime, flight.Duration))
+		}
+	}
+	return issues
+}
+
+// tells if either end of the flight is a known city outside India
+func flightLeavesIndia(flight *models.Flight) bool {
+	mapper := GetCityStateMapper()
+	_, sourceAbroad := mapper.ResolveForeignCity(flight.Source)
+	_, destAbroad := mapper.ResolveForeignCity(flight.Destination)
+	return sourceAbroad || destAbroad
+}
+
+// parses a clock time like "22:20" and returns it as an offset from midnight
+func parseClock(value string) (time.Duration, bool) {
+	value = strings.TrimSpace(value)
+	for _, layout := range clockLayouts {
+		if t, err := time.Parse(layout, value); err == nil {
+			return time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute + time.Duration(t.Second())*time.Second, true
+		}
+	}
+	return 0, false
+}
+
+// parses arrival values like "13:15", "01:10 22 Mar", "01:10 22 Mar 2019" or "01:10 +1 day"
+// the bool in the middle tells if the value carried its own date
+func parseArrival(value string, departure time.Time, expected time.Duration) (time.Time, bool, bool) {
+	parts := strings.Fields(value)
+	if len(parts) == 0 {
+		return time.Time{}, false, false
+	}
+	clock, ok := parseClock(parts[0])
+	if !ok {
+		return time.Time{}, false, false
+	}
+	depDay := time.Date(departure.Year(), departure.Month(), departure.Day(), 0, 0, 0, 0, istLocation)
+	rest := strings.Join(parts[1:], " ")
+
+	// no date at all - same day, or the next one if the clock went past midnight
+	if rest == "" {
+		return rollForward(departure, depDay.Add(clock), expected), false, true
+	}
+
+	// "+1 day", "+2 days", "(+1)"...
+	if offset := strings.Trim(rest, "()"); strings.HasPrefix(offset, "+") {
+		days, err := strconv.Atoi(cleanNonNumeric(strings.Fields(offset)[0]))
+		if err != nil {
+			return time.Time{}, false, false
+		}
+		return depDay.AddDate(0, 0, days).Add(clock), false, true
+	}
+
+	// embedded day and month, the year comes from the departure unless it's given too
+	if t, err := time.ParseInLocation("2 Jan 2006", rest, istLocation); err == nil {
+		return t.Add(clock), true, true
+	}
+	if t, err := time.ParseInLocation("2 Jan", rest, istLocation); err == nil {
+		arrDay := time.Date(departure.Year(), t.Month(), t.Day(), 0, 0, 0, 0, istLocation)
+		// a December departure landing in January
+		if arrDay.Before(depDay.AddDate(0, -6, 0)) {
+			arrDay = arrDay.AddDate(1, 0, 0)
+		}
+		return arrDay.Add(clock), true, true
+	}
+	return time.Time{}, false, false
+}
+
+// moves an arrival forward a day at a time (keeping its clock) until it's not before the departure
+// when the expected duration is known it keeps going to the day that lands closest to it
+func rollForward(departure, arrival time.Time, expected time.Duration) time.Time {
+	depDay := time.Date(departure.Year(), departure.Month(), departure.Day(), 0, 0, 0, 0, istLocation)
+	clock := time.Duration(arrival.Hour())*time.Hour + time.Duration(arrival.Minute())*time.Minute
+	rolled := depDay.Add(clock)
+	for rolled.Before(departure) {
+		rolled = rolled.AddDate(0, 0, 1)
+	}
+	if expected > 0 {
+		target := departure.Add(expected)
+		for next := rolled.AddDate(0, 0, 1); absDuration(next.Sub(target)) < absDuration(rolled.Sub(target)); next = next.AddDate(0, 0, 1) {
+			rolled = next
+		}
+	}
+	return rolled
+}
+
+func absDuration(d time.Duration) time.Duration {
+	if d < 0 {
+		return -d
+	}
+	return d
+}
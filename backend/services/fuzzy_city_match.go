@@ -0,0 +1,176 @@
+package services
+
+import (
+	"log"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// default minimum confidence for a fuzzy match to count - overridden with CITY_MATCH_THRESHOLD
+const defaultCityMatchThreshold = 0.85
+
+// names shorter than this are too easy to confuse ("una", "mon") to match fuzzily
+const minFuzzyCityLength = 4
+
+// two candidates in different states this close together make the match ambiguous
+const fuzzyAmbiguityMargin = 0.02
+
+// a known city a raw name might be meant as, with how sure we are
+type CitySuggestion struct {
+	City       string  `json:"city"`
+	State      string  `json:"state"`
+	Confidence float64 `json:"confidence"`
+}
+
+// reads CITY_MATCH_THRESHOLD once, falling back to the default when it's missing or not in (0, 1]
+func cityMatchThreshold() float64 {
+	raw := os.Getenv("CITY_MATCH_THRESHOLD")
+	if raw == "" {
+		return defaultCityMatchThreshold
+	}
+	threshold, err := strconv.ParseFloat(raw, 64)
+	if err != nil || threshold <= 0 || threshold > 1 {
+		log.Printf("Ignoring CITY_MATCH_THRESHOLD=%q, using %.2f", raw, defaultCityMatchThreshold)
+		return defaultCityMatchThreshold
+	}
+	return threshold
+}
+
+// finds the known city a misspelt name most likely means - the caller holds the mapper's read lock
+// returns false when nothing clears the threshold or two states are equally likely
+func (csm *CityStateMapper) fuzzyMatch(normalizedCity string) (CitySuggestion, bool) {
+	csm.fuzzyMutex.Lock()
+	defer csm.fuzzyMutex.Unlock()
+
+	if match, cached := csm.fuzzyCache[normalizedCity]; cached {
+		return match, match.City != ""
+	}
+
+	var match CitySuggestion
+	if len(normalizedCity) >= minFuzzyCityLength {
+		suggestions := csm.rankCities(normalizedCity, 2)
+		if len(suggestions) > 0 && suggestions[0].Confidence >= csm.matchThreshold {
+			ambiguous := len(suggestions) > 1 && suggestions[1].State != suggestions[0].State &&
+				suggestions[0].Confidence-suggestions[1].Confidence < fuzzyAmbiguityMargin
+			if !ambiguous {
+				match = suggestions[0]
+			}
+		}
+	}
+	csm.fuzzyCache[normalizedCity] = match
+	return match, match.City != ""
+}
+
+// returns up to limit known cities closest to the given name, best first
+func (csm *CityStateMapper) SuggestCities(city string, limit int) []CitySuggestion {
+	csm.mutex.RLock()
+	defer csm.mutex.RUnlock()
+	return csm.rankCities(normalizeCityName(city), limit)
+}
+
+// scores every known city and alias against the name - one suggestion per city, best first
+// the caller holds the mapper's read lock
+func (csm *CityStateMapper) rankCities(normalizedCity string, limit int) []CitySuggestion {
+	best := make(map[string]CitySuggestion)
+	consider := func(candidate, city, state string) {
+		confidence := cityNameSimilarity(normalizedCity, candidate)
+		if current, exists := best[city]; !exists || confidence > current.Confidence {
+			best[city] = CitySuggestion{City: city, State: state, Confidence: confidence}
+		}
+	}
+
+	for city, state := range csm.cityToStateMap {
+		consider(city, city, state)
+	}
+	for alias, city := range csm.aliases {
+		if state, exists := csm.cityToStateMap[city]; exists {
+			consider(alias, city, state)
+		}
+	}
+
+	suggestions := make([]CitySuggestion, 0, len(best))
+	for _, suggestion := range best {
+		suggestions = append(suggestions, suggestion)
+	}
+	sort.Slice(suggestions, func(i, j int) bool {
+		if suggestions[i].Confidence != suggestions[j].Confidence {
+			return suggestions[i].Confidence > suggestions[j].Confidence
+		}
+		return suggestions[i].City < suggestions[j].City
+	})
+	if len(suggestions) > limit {
+		suggestions = suggestions[:limit]
+	}
+	return suggestions
+}
+
+// similarity between two city names from 0 to 1 - mostly spelling, partly how they sound
+func cityNameSimilarity(a, b string) float64 {
+	spelling := 1 - float64(editDistance(a, b))/float64(max(len(a), len(b)))
+	keyA, keyB := phoneticKey(a), phoneticKey(b)
+	sound := 0.0
+	if longest := max(len(keyA), len(keyB)); longest > 0 {
+		sound = 1 - float64(editDistance(keyA, keyB))/float64(longest)
+	}
+	score := 0.6*spelling + 0.4*sound
+	return float64(int(score*1000+0.5)) / 1000
+}
+
+// optimal string alignment distance - like Levenshtein but a swapped pair ("dehli") costs one edit
+func editDistance(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	prev2 := make([]int, len(rb)+1)
+	prev := make([]int, len(rb)+1)
+	curr := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+
+	for i := 1; i <= len(ra); i++ {
+		curr[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+			if i > 1 && j > 1 && ra[i-1] == rb[j-2] && ra[i-2] == rb[j-1] {
+				curr[j] = min(curr[j], prev2[j-2]+1)
+			}
+		}
+		prev2, prev, curr = prev, curr, prev2
+	}
+	return prev[len(rb)]
+}
+
+// spelling variants that come from transliterating the same Indian name differently
+var phoneticReplacer = strings.NewReplacer(
+	"ph", "f", "bh", "b", "dh", "d", "gh", "g", "kh", "k", "th", "t", "sh", "s",
+	"ch", "c", "ck", "k", "q", "k", "w", "v", "z", "j", "x", "ks", "y", "i",
+)
+
+// reduces a name to its consonant skeleton so "banglore", "bangalore" and "bengaluru" sound alike
+func phoneticKey(name string) string {
+	var letters strings.Builder
+	for _, r := range strings.ToLower(name) {
+		if r >= 'a' && r <= 'z' {
+			letters.WriteRune(r)
+		}
+	}
+	simplified := phoneticReplacer.Replace(letters.String())
+	if simplified == "" {
+		return ""
+	}
+
+	key := []byte{simplified[0]}
+	for i := 1; i < len(simplified); i++ {
+		c := simplified[i]
+		if strings.IndexByte("aeiou", c) >= 0 || c == key[len(key)-1] {
+			continue
+		}
+		key = append(key, c)
+	}
+	return string(key)
+}
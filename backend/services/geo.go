@@ -0,0 +1,61 @@
+package services
+
+import (
+	"math"
+
+	"flight-dashboard-backend/models"
+)
+
+const earthRadiusKm = 6371.0
+
+// the block time a direct flight over a distance should take - cruise speed plus a fixed
+// allowance for taxiing, climb and descent, close to scheduled times on Indian domestic sectors
+const (
+	cruiseSpeedKmh     = 750.0
+	blockOverheadHours = 0.5
+)
+
+// great-circle distance between two points in kilometres (haversine)
+func greatCircleKm(lat1, lon1, lat2, lon2 float64) float64 {
+	toRadians := func(degrees float64) float64 { return degrees * math.Pi / 180 }
+	dLat := toRadians(lat2 - lat1)
+	dLon := toRadians(lon2 - lon1)
+	a := math.Sin(dLat/2)*math.Sin(dLat/2) +
+		math.Cos(toRadians(lat1))*math.Cos(toRadians(lat2))*math.Sin(dLon/2)*math.Sin(dLon/2)
+	return 2 * earthRadiusKm * math.Asin(math.Sqrt(a))
+}
+
+// expected block time in hours of a non-stop flight over the distance
+func expectedBlockTime(distanceKm float64) float64 {
+	return blockOverheadHours + distanceKm/cruiseSpeedKmh
+}
+
+// finds the airport whose coordinates stand for a city - by airport code, then by the resolved city
+// name, and finally by any airport whose city resolves to the same city (Prayagraj for Allahabad)
+func (csm *CityStateMapper) locateCity(city string) (models.Airport, bool) {
+	registry := GetAirportRegistry()
+	if airport, exists := registry.GetAirport(city); exists {
+		return airport, true
+	}
+	canonical := csm.canonicalCity(city)
+	if airport, exists := registry.GetAirportForCity(canonical); exists {
+		return airport, true
+	}
+	if airport, exists := registry.GetAirportForCity(city); exists {
+		return airport, true
+	}
+	for _, airport := range registry.GetAllAirports() {
+		if csm.canonicalCity(airport.City) == canonical {
+			return airport, true
+		}
+	}
+	return models.Airport{}, false
+}
+
+// hours a flight took - the duration column, or the departure-to-arrival block time when it has none
+func flightHours(flight *models.Flight) float64 {
+	if flight.Duration > 0 {
+		return flight.Duration
+	}
+	return flight.BlockTime
+}
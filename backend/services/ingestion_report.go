@@ -0,0 +1,111 @@
+package services
+
+import (
+	"encoding/csv"
+	"io"
+	"strconv"
+	"time"
+)
+
+// what happened to a row that had a problem
+const (
+	IssueDropped = "dropped" // the whole row was left out of the dataset
+	IssueCoerced = "coerced" // the row was kept but a value was replaced with a default
+	IssueFlagged = "flagged" // the row was kept as is but its values don't add up
+)
+
+// we keep at most this many issues per load so one broken file can't eat all the memory
+const maxReportedIssues = 10000
+
+// one problem found while ingesting a row
+type IngestionIssue struct {
+	File     string `json:"file,omitempty"` // dataset file the row came from
+	Line     int    `json:"line"`           // line number in the source file (header is line 1)
+	Column   string `json:"column"`         // column that failed, "*" when the row itself was unreadable
+	RawValue string `json:"raw_value"`      // value as it appeared in the file
+	Action   string `json:"action"`         // IssueDropped, IssueCoerced or IssueFlagged
+	Reason   string `json:"reason"`
+}
+
+// summary of one dataset load - how many rows made it in, how many were thrown away and why
+type IngestionReport struct {
+	Source            string                 `json:"source"`
+	AcceptedRows      int                    `json:"accepted_rows"`
+	RejectedRows      int                    `json:"rejected_rows"`
+	CoercedRows       int                    `json:"coerced_rows"` // kept rows with at least one coerced value
+	FlaggedRows       int                    `json:"flagged_rows"` // kept rows with values that don't add up
+	DedupStrategy     string                 `json:"dedup_strategy,omitempty"`
+	Duplicates        int                    `json:"duplicates"`         // rows that repeated an earlier row's dedup key
+	DuplicatesRemoved int                    `json:"duplicates_removed"` // of those, how many were left out of the dataset
+	Issues            []IngestionIssue       `json:"issues"`
+	IssuesTruncated   bool                   `json:"issues_truncated"` // true when more than maxReportedIssues were found
+	Files             []FileIngestionSummary `json:"files,omitempty"`  // per-file counts when the dataset spans several files
+	LoadedAt          time.Time              `json:"loaded_at"`
+}
+
+// counts for one file of a multi-file dataset
+type FileIngestionSummary struct {
+	File         string `json:"file"`
+	AcceptedRows int    `json:"accepted_rows"`
+	RejectedRows int    `json:"rejected_rows"`
+	CoercedRows  int    `json:"coerced_rows"`
+	FlaggedRows  int    `json:"flagged_rows"`
+	Error        string `json:"error,omitempty"` // set when the file couldn't be loaded at all
+}
+
+// adds a single file's report to a combined one, tagging its issues with the file
+func (ir *IngestionReport) merge(fileReport IngestionReport, file string) {
+	ir.AcceptedRows += fileReport.AcceptedRows
+	ir.RejectedRows += fileReport.RejectedRows
+	ir.CoercedRows += fileReport.CoercedRows
+	ir.FlaggedRows += fileReport.FlaggedRows
+	for _, issue := range fileReport.Issues {
+		issue.File = file
+		ir.addIssues(issue)
+	}
+	if fileReport.IssuesTruncated {
+		ir.IssuesTruncated = true
+	}
+}
+
+// adds issues to the report, stopping at maxReportedIssues
+func (ir *IngestionReport) addIssues(issues ...IngestionIssue) {
+	for _, issue := range issues {
+		if len(ir.Issues) >= maxReportedIssues {
+			ir.IssuesTruncated = true
+			return
+		}
+		ir.Issues = append(ir.Issues, issue)
+	}
+}
+
+// counts a kept row towards CoercedRows / FlaggedRows based on its issues
+func (ir *IngestionReport) countRowIssues(issues []IngestionIssue) {
+	coerced, flagged := false, false
+	for _, issue := range issues {
+		coerced = coerced || issue.Action == IssueCoerced
+		flagged = flagged || issue.Action == IssueFlagged
+	}
+	if coerced {
+		ir.CoercedRows++
+	}
+	if flagged {
+		ir.FlaggedRows++
+	}
+}
+
+// writes the issues as CSV so the data team can open them next to the source file
+func (ir *IngestionReport) WriteIssuesCSV(w io.Writer) error {
+	writer := csv.NewWriter(w)
+	if err := writer.Write([]string{"file", "line", "column", "raw_value", "action", "reason"}); err != nil {
+		return err
+	}
+	for _, issue := range ir.Issues {
+		row := []string{issue.File, strconv.Itoa(issue.Line), issue.Column, issue.RawValue, issue.Action, issue.Reason}
+		if err := writer.Write(row); err != nil {
+			return err
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
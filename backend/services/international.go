@@ -0,0 +1,76 @@
+package services
+
+import (
+	"sort"
+)
+
+// a city abroad and how many flights go there
+type InternationalDestination struct {
+	City    string `json:"city"`
+	Country string `json:"country"`
+	Flights int    `json:"flights"`
+}
+
+// international flights of one Indian state
+type StateInternational struct {
+	State                 string                     `json:"state"`
+	Code                  string                     `json:"code"`
+	Slug                  string                     `json:"slug"`
+	InternationalOutgoing int                        `json:"international_outgoing"`
+	InternationalIncoming int                        `json:"international_incoming"`
+	Destinations          []InternationalDestination `json:"destinations"` // most flights first
+	Countries             map[string]int             `json:"countries"`    // outgoing flights per country
+}
+
+// returns the foreign destinations of every state with international flights, most outgoing flights first
+func (sa *StateAggregator) GetInternationalByState() []StateInternational {
+	result := []StateInternational{}
+	for name, agg := range sa.GetAllAggregations() {
+		if agg.InternationalOutgoing == 0 && agg.InternationalIncoming == 0 {
+			continue
+		}
+		entry := StateInternational{
+			State:                 name,
+			InternationalOutgoing: agg.InternationalOutgoing,
+			InternationalIncoming: agg.InternationalIncoming,
+			Destinations:          make([]InternationalDestination, 0, len(agg.InternationalDestinations)),
+			Countries:             make(map[string]int),
+		}
+		if state, ok := GetStateRegistry().Resolve(name); ok {
+			entry.Code = state.Code
+			entry.Slug = state.Slug
+		}
+		for city, flights := range agg.InternationalDestinations {
+			foreign, _ := sa.mapper.lookupForeignCity(city)
+			entry.Destinations = append(entry.Destinations, InternationalDestination{City: city, Country: foreign.Country, Flights: flights})
+			entry.Countries[foreign.Country] += flights
+		}
+		sort.Slice(entry.Destinations, func(i, j int) bool {
+			if entry.Destinations[i].Flights != entry.Destinations[j].Flights {
+				return entry.Destinations[i].Flights > entry.Destinations[j].Flights
+			}
+			return entry.Destinations[i].City < entry.Destinations[j].City
+		})
+		result = append(result, entry)
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].InternationalOutgoing != result[j].InternationalOutgoing {
+			return result[i].InternationalOutgoing > result[j].InternationalOutgoing
+		}
+		return result[i].State < result[j].State
+	})
+	return result
+}
+
+// returns how many flights are domestic, international, foreign or unknown
+func (sa *StateAggregator) GetFlightScopes() map[string]int {
+	sa.mutex.RLock()
+	defer sa.mutex.RUnlock()
+
+	result := map[string]int{FlightDomestic: 0, FlightInternational: 0, FlightForeign: 0, FlightUnclassified: 0}
+	for scope, flights := range sa.scopes {
+		result[scope] = flights
+	}
+	return result
+}
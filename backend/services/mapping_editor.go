@@ -0,0 +1,376 @@
+package services
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"sort"
+	"strings"
+	"time"
+)
+
+// returned for mapping changes that would leave the mapping inconsistent
+var ErrInvalidMapping = errors.New("invalid mapping change")
+
+// returned when the city or alias being changed doesn't exist
+var ErrMappingNotFound = errors.New("mapping not found")
+
+// append-only log of every mapping change, one JSON object per line
+const mappingAuditPath = "data/mapping_audit.jsonl"
+
+// kinds of mapping changes recorded in the audit trail
+const (
+	MappingSetCity     = "set_city"
+	MappingRenameCity  = "rename_city"
+	MappingRemoveCity  = "remove_city"
+	MappingSetAlias    = "set_alias"
+	MappingRemoveAlias = "remove_alias"
+)
+
+// one entry of the audit trail - who changed which city or alias, when, and from what to what
+type MappingChange struct {
+	Time    time.Time `json:"time"`
+	User    string    `json:"user"`
+	Action  string    `json:"action"`
+	Name    string    `json:"name"`              // the city or alias that was changed
+	Before  string    `json:"before,omitempty"`  // state, city or name before the change
+	After   string    `json:"after,omitempty"`   // state, city or name after the change
+	Details string    `json:"details,omitempty"` // side effects, e.g. aliases that moved with a renamed city
+}
+
+// maps a city to a state - adds the city or moves it to another state
+func (csm *CityStateMapper) SetCityState(city, state, user string) (MappingChange, error) {
+	city = strings.ToLower(strings.TrimSpace(city))
+	state = strings.ToLower(GetStateRegistry().CanonicalName(state))
+
+	return csm.applyChange(user, MappingSetCity, city, func(cities, aliases map[string]string, change *MappingChange) error {
+		if city == "" {
+			return fmt.Errorf("%w: city can't be empty", ErrInvalidMapping)
+		}
+		if !isKnownState(state) {
+			return fmt.Errorf("%w: unknown state %q", ErrInvalidMapping, state)
+		}
+		if target, isAlias := aliases[city]; isAlias && target != city {
+			return fmt.Errorf("%w: %q is an alias of %q", ErrInvalidMapping, city, target)
+		}
+		if cities[city] == state {
+			return fmt.Errorf("%w: %q is already mapped to %q", ErrInvalidMapping, city, state)
+		}
+		change.Before = cities[city]
+		change.After = state
+		cities[city] = state
+		return nil
+	})
+}
+
+// renames a city, keeping its state - aliases pointing at the old name follow it
+func (csm *CityStateMapper) RenameCity(city, newName, user string) (MappingChange, error) {
+	city = strings.ToLower(strings.TrimSpace(city))
+	newName = strings.ToLower(strings.TrimSpace(newName))
+
+	return csm.applyChange(user, MappingRenameCity, city, func(cities, aliases map[string]string, change *MappingChange) error {
+		state, exists := cities[city]
+		if !exists {
+			return fmt.Errorf("%w: city %q", ErrMappingNotFound, city)
+		}
+		if newName == "" || newName == city {
+			return fmt.Errorf("%w: new name must be different and not empty", ErrInvalidMapping)
+		}
+		if _, taken := cities[newName]; taken {
+			return fmt.Errorf("%w: %q is already a city", ErrInvalidMapping, newName)
+		}
+		if target, isAlias := aliases[newName]; isAlias && target != city {
+			return fmt.Errorf("%w: %q is an alias of %q", ErrInvalidMapping, newName, target)
+		}
+
+		delete(cities, city)
+		cities[newName] = state
+		delete(aliases, newName)
+		var moved []string
+		for alias, target := range aliases {
+			if target == city {
+				aliases[alias] = newName
+				moved = append(moved, alias)
+			}
+		}
+		change.Before = city
+		change.After = newName
+		if len(moved) > 0 {
+			sort.Strings(moved)
+			change.Details = "aliases moved: " + strings.Join(moved, ", ")
+		}
+		return nil
+	})
+}
+
+// removes a city from the mapping along with the aliases pointing at it
+func (csm *CityStateMapper) RemoveCity(city, user string) (MappingChange, error) {
+	city = strings.ToLower(strings.TrimSpace(city))
+
+	return csm.applyChange(user, MappingRemoveCity, city, func(cities, aliases map[string]string, change *MappingChange) error {
+		state, exists := cities[city]
+		if !exists {
+			return fmt.Errorf("%w: city %q", ErrMappingNotFound, city)
+		}
+		delete(cities, city)
+		var removed []string
+		for alias, target := range aliases {
+			if target == city {
+				delete(aliases, alias)
+				removed = append(removed, alias)
+			}
+		}
+		change.Before = state
+		if len(removed) > 0 {
+			sort.Strings(removed)
+			change.Details = "aliases removed: " + strings.Join(removed, ", ")
+		}
+		return nil
+	})
+}
+
+// points an alias at a known city - adds the alias or re-targets it
+func (csm *CityStateMapper) SetAlias(alias, city, user string) (MappingChange, error) {
+	alias = strings.ToLower(strings.TrimSpace(alias))
+	city = strings.ToLower(strings.TrimSpace(city))
+
+	return csm.applyChange(user, MappingSetAlias, alias, func(cities, aliases map[string]string, change *MappingChange) error {
+		if alias == "" {
+			return fmt.Errorf("%w: alias can't be empty", ErrInvalidMapping)
+		}
+		if _, isCity := cities[alias]; isCity {
+			return fmt.Errorf("%w: %q is a city, not an alias", ErrInvalidMapping, alias)
+		}
+		if _, exists := cities[city]; !exists {
+			return fmt.Errorf("%w: alias must point at a mapped city, %q isn't one", ErrInvalidMapping, city)
+		}
+		if aliases[alias] == city {
+			return fmt.Errorf("%w: %q already points at %q", ErrInvalidMapping, alias, city)
+		}
+		change.Before = aliases[alias]
+		change.After = city
+		aliases[alias] = city
+		return nil
+	})
+}
+
+// removes an alias
+func (csm *CityStateMapper) RemoveAlias(alias, user string) (MappingChange, error) {
+	alias = strings.ToLower(strings.TrimSpace(alias))
+
+	return csm.applyChange(user, MappingRemoveAlias, alias, func(cities, aliases map[string]string, change *MappingChange) error {
+		city, exists := aliases[alias]
+		if !exists {
+			return fmt.Errorf("%w: alias %q", ErrMappingNotFound, alias)
+		}
+		delete(aliases, alias)
+		change.Before = city
+		return nil
+	})
+}
+
+// runs an edit on copies of the mapping, saves them, records the change, swaps them in and recomputes the aggregations
+// nothing changes in memory or on disk if the edit is rejected, can't be saved or can't be audited
+func (csm *CityStateMapper) applyChange(user, action, name string, edit func(cities, aliases map[string]string, change *MappingChange) error) (MappingChange, error) {
+	change := MappingChange{Time: time.Now(), User: user, Action: action, Name: name}
+
+	csm.mutex.Lock()
+	cities := make(map[string]string, len(csm.cityToStateMap))
+	for city, state := range csm.cityToStateMap {
+		cities[city] = state
+	}
+	aliases := make(map[string]string, len(csm.aliases))
+	for alias, city := range csm.aliases {
+		aliases[alias] = city
+	}
+
+	if err := edit(cities, aliases, &change); err != nil {
+		csm.mutex.Unlock()
+		return change, err
+	}
+	// the two files are one change - if either can't be replaced both go back to what they were
+	previous, err := readMappingFiles(cityStateMapPath, cityAliasesPath)
+	if err != nil {
+		csm.mutex.Unlock()
+		return change, err
+	}
+	if err := saveMapping(cities, aliases); err != nil {
+		restoreMappingFiles(previous)
+		csm.mutex.Unlock()
+		return change, err
+	}
+	// an unaudited change doesn't go through - the files are rolled back and memory is never touched
+	if err := appendMappingAudit(mappingAuditPath, change); err != nil {
+		restoreMappingFiles(previous)
+		csm.mutex.Unlock()
+		return change, fmt.Errorf("failed to record mapping change in audit trail: %v", err)
+	}
+
+	csm.cityToStateMap = cities
+	csm.aliases = aliases
+	csm.fuzzyMutex.Lock()
+	csm.fuzzyCache = make(map[string]CitySuggestion)
+	csm.fuzzyMutex.Unlock()
+	csm.mutex.Unlock()
+
+	log.Printf("Mapping changed by %s: %s %s (%s -> %s)", user, action, name, change.Before, change.After)
+
+	// aggregations read the mapper, so this has to run after the write lock is released
+	// it goes through the reloader so it can't overwrite the aggregations of a dataset swapped in meanwhile
+	GetDatasetReloader().Recompute()
+	return change, nil
+}
+
+// returns the most recent mapping changes, newest first - all of them when limit is 0
+func (csm *CityStateMapper) GetMappingAudit(limit int) ([]MappingChange, error) {
+	changes := []MappingChange{}
+
+	file, err := os.Open(mappingAuditPath)
+	if errors.Is(err, os.ErrNotExist) {
+		return changes, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to open audit trail: %v", err)
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		var change MappingChange
+		if err := json.Unmarshal(scanner.Bytes(), &change); err != nil {
+			continue
+		}
+		changes = append(changes, change)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read audit trail: %v", err)
+	}
+
+	for i, j := 0, len(changes)-1; i < j; i, j = i+1, j-1 {
+		changes[i], changes[j] = changes[j], changes[i]
+	}
+	if limit > 0 && len(changes) > limit {
+		changes = changes[:limit]
+	}
+	return changes, nil
+}
+
+// writes the city map and the aliases together - both go to temp files first and are only renamed over
+// the real files once both were written, so a failed write leaves both files untouched
+func saveMapping(cities, aliases map[string]string) error {
+	aliasesContent, err := encodeCityAliases(aliases)
+	if err != nil {
+		return err
+	}
+	citiesTmp, err := writeTempFile(cityStateMapPath, encodeCityStateMap(cities))
+	if err != nil {
+		return err
+	}
+	defer os.Remove(citiesTmp)
+	aliasesTmp, err := writeTempFile(cityAliasesPath, aliasesContent)
+	if err != nil {
+		return err
+	}
+	defer os.Remove(aliasesTmp)
+
+	if err := os.Rename(citiesTmp, cityStateMapPath); err != nil {
+		return fmt.Errorf("failed to replace %s: %v", cityStateMapPath, err)
+	}
+	if err := os.Rename(aliasesTmp, cityAliasesPath); err != nil {
+		return fmt.Errorf("failed to replace %s: %v", cityAliasesPath, err)
+	}
+	return nil
+}
+
+// contents of the mapping files before a change, nil for a file that doesn't exist yet
+// a file that exists but can't be read fails the change, as it couldn't be restored
+func readMappingFiles(paths ...string) (map[string][]byte, error) {
+	contents := make(map[string][]byte, len(paths))
+	for _, path := range paths {
+		content, err := os.ReadFile(path)
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			return nil, fmt.Errorf("failed to read %s: %v", path, err)
+		}
+		contents[path] = content
+	}
+	return contents, nil
+}
+
+// puts the mapping files back the way readMappingFiles found them
+func restoreMappingFiles(contents map[string][]byte) {
+	for path, content := range contents {
+		var err error
+		if content == nil {
+			err = os.Remove(path)
+			if errors.Is(err, os.ErrNotExist) {
+				err = nil
+			}
+		} else {
+			err = writeFileAtomically(path, content)
+		}
+		if err != nil {
+			log.Printf("Warning: Could not restore %s after a failed mapping change: %v", path, err)
+		}
+	}
+}
+
+// the mapping in the same layout as the file we ship - one state per entry, cities on one line
+func encodeCityStateMap(cities map[string]string) []byte {
+	byState := make(map[string][]string)
+	for city, state := range cities {
+		byState[state] = append(byState[state], city)
+	}
+	states := make([]string, 0, len(byState))
+	for state := range byState {
+		states = append(states, state)
+	}
+	sort.Strings(states)
+
+	var out strings.Builder
+	out.WriteString("{\n")
+	for i, state := range states {
+		sort.Strings(byState[state])
+		quoted := make([]string, len(byState[state]))
+		for j, city := range byState[state] {
+			name, _ := json.Marshal(city)
+			quoted[j] = string(name)
+		}
+		name, _ := json.Marshal(state)
+		fmt.Fprintf(&out, "  %s: [\n    %s\n  ]", name, strings.Join(quoted, ", "))
+		if i < len(states)-1 {
+			out.WriteString(",")
+		}
+		out.WriteString("\n")
+	}
+	out.WriteString("}\n")
+	return []byte(out.String())
+}
+
+// the aliases as a flat alias -> city object
+func encodeCityAliases(aliases map[string]string) ([]byte, error) {
+	data, err := json.MarshalIndent(aliases, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode city aliases: %v", err)
+	}
+	return append(data, '\n'), nil
+}
+
+// appends one change to the audit trail
+func appendMappingAudit(path string, change MappingChange) error {
+	line, err := json.Marshal(change)
+	if err != nil {
+		return err
+	}
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+	if _, err := file.Write(append(line, '\n')); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
@@ -0,0 +1,241 @@
+package services
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"flight-dashboard-backend/models"
+)
+
+// how bad a mapping issue is - errors are conflicts that make lookups depend on luck
+const (
+	MappingSeverityError   = "error"
+	MappingSeverityWarning = "warning"
+)
+
+// kinds of mapping issues the validator reports
+const (
+	MappingIssueDuplicateCity = "duplicate_city" // city listed under more than one state
+	MappingIssueUnknownState  = "unknown_state"  // state name that isn't an Indian state or union territory
+	MappingIssueDanglingAlias = "dangling_alias" // alias pointing at a city that isn't mapped
+	MappingIssueMissingAlias  = "missing_alias"  // airport city that only resolves by guessing, or not at all
+	MappingIssueUnreadable    = "unreadable"     // mapping file missing or not valid JSON
+	MappingIssueRegion        = "region"         // state in more than one region, or in none
+)
+
+// one problem found in a mapping source
+type MappingIssue struct {
+	Severity string   `json:"severity"`
+	Kind     string   `json:"kind"`
+	Source   string   `json:"source"` // file the problem is in, or "default" for the built-in map
+	City     string   `json:"city,omitempty"`
+	States   []string `json:"states,omitempty"`
+	Message  string   `json:"message"`
+}
+
+// everything the validator found across the mapping sources
+type MappingValidationReport struct {
+	Valid     bool           `json:"valid"` // no errors - warnings are allowed
+	Errors    int            `json:"errors"`
+	Warnings  int            `json:"warnings"`
+	Issues    []MappingIssue `json:"issues"`
+	CheckedAt time.Time      `json:"checked_at"`
+}
+
+// checks data/city_state_map.json, the built-in default map, the aliases, the airport registry and the regions
+func ValidateMapping() MappingValidationReport {
+	report := MappingValidationReport{Issues: []MappingIssue{}}
+
+	if lists, err := readCityStateLists(cityStateMapPath); err != nil {
+		report.add(MappingIssue{Severity: MappingSeverityWarning, Kind: MappingIssueUnreadable, Source: cityStateMapPath,
+			Message: fmt.Sprintf("could not read the mapping file, the default map is used: %v", err)})
+	} else {
+		report.checkCityStateLists(lists, cityStateMapPath)
+	}
+	report.checkCityStateLists(defaultCityStateLists(), "default")
+
+	mapper := GetCityStateMapper()
+	mapper.mutex.RLock()
+	cities := mapper.cityToStateMap
+	aliases := mapper.aliases
+	mapper.mutex.RUnlock()
+	report.checkAliases(cities, aliases)
+	report.checkAirportCities(cities, aliases)
+
+	if regions, err := readRegions(regionsPath); err != nil {
+		report.add(MappingIssue{Severity: MappingSeverityWarning, Kind: MappingIssueUnreadable, Source: regionsPath,
+			Message: fmt.Sprintf("could not read the regions file, the default regions are used: %v", err)})
+	} else {
+		report.checkRegions(regions, regionsPath)
+	}
+
+	sort.SliceStable(report.Issues, func(i, j int) bool {
+		if report.Issues[i].Severity != report.Issues[j].Severity {
+			return report.Issues[i].Severity == MappingSeverityError
+		}
+		return false
+	})
+	report.Valid = report.Errors == 0
+	report.CheckedAt = time.Now()
+	return report
+}
+
+// reads the raw state -> cities lists from the mapping file
+func readCityStateLists(path string) (map[string][]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var lists map[string][]string
+	if err := json.Unmarshal(data, &lists); err != nil {
+		return nil, err
+	}
+	return lists, nil
+}
+
+// reports cities under more than one state and states we don't know
+func (r *MappingValidationReport) checkCityStateLists(lists map[string][]string, source string) {
+	statesOfCity := make(map[string][]string)
+	states := make([]string, 0, len(lists))
+	for state := range lists {
+		states = append(states, state)
+	}
+	sort.Strings(states)
+
+	for _, state := range states {
+		normalizedState := strings.ToLower(strings.TrimSpace(state))
+		if !isKnownState(normalizedState) {
+			r.add(MappingIssue{Severity: MappingSeverityError, Kind: MappingIssueUnknownState, Source: source,
+				States: []string{normalizedState}, Message: fmt.Sprintf("%q is not an Indian state or union territory", state)})
+		}
+		for _, city := range lists[state] {
+			normalizedCity := strings.ToLower(strings.TrimSpace(city))
+			statesOfCity[normalizedCity] = append(statesOfCity[normalizedCity], normalizedState)
+		}
+	}
+
+	cities := make([]string, 0, len(statesOfCity))
+	for city := range statesOfCity {
+		cities = append(cities, city)
+	}
+	sort.Strings(cities)
+
+	for _, city := range cities {
+		listed := statesOfCity[city]
+		if len(listed) < 2 {
+			continue
+		}
+		distinct := uniqueSorted(listed)
+		if len(distinct) == 1 {
+			r.add(MappingIssue{Severity: MappingSeverityWarning, Kind: MappingIssueDuplicateCity, Source: source, City: city,
+				States: distinct, Message: fmt.Sprintf("%q is listed %d times under %q", city, len(listed), distinct[0])})
+			continue
+		}
+		r.add(MappingIssue{Severity: MappingSeverityError, Kind: MappingIssueDuplicateCity, Source: source, City: city,
+			States: distinct, Message: fmt.Sprintf("%q is under %s, only %q is used", city, strings.Join(quoteAll(distinct), " and "), distinct[0])})
+	}
+}
+
+// reports aliases that point at cities the mapping doesn't have
+func (r *MappingValidationReport) checkAliases(cities, aliases map[string]string) {
+	names := make([]string, 0, len(aliases))
+	for alias := range aliases {
+		names = append(names, alias)
+	}
+	sort.Strings(names)
+
+	for _, alias := range names {
+		if _, exists := cities[aliases[alias]]; !exists {
+			r.add(MappingIssue{Severity: MappingSeverityError, Kind: MappingIssueDanglingAlias, Source: cityAliasesPath, City: alias,
+				Message: fmt.Sprintf("alias %q points at %q, which isn't a mapped city", alias, aliases[alias])})
+		}
+	}
+}
+
+// reports airport cities that aren't mapped and have no alias - flights named after them only resolve by luck
+func (r *MappingValidationReport) checkAirportCities(cities, aliases map[string]string) {
+	seen := make(map[string]bool)
+	for _, airport := range GetAirportRegistry().GetAllAirports() {
+		city := strings.ToLower(strings.TrimSpace(airport.City))
+		if city == "" || seen[city] || !strings.EqualFold(airport.Country, domesticCountry) {
+			continue
+		}
+		seen[city] = true
+
+		state, exists := cities[normalizeCityName(city)]
+		if !exists {
+			state, exists = cities[aliases[city]]
+		}
+		airportState := strings.ToLower(airport.State)
+		switch {
+		case !exists:
+			r.add(MappingIssue{Severity: MappingSeverityWarning, Kind: MappingIssueMissingAlias, Source: cityAliasesPath, City: city,
+				States: []string{airportState}, Message: fmt.Sprintf("airport city %q (%s) isn't mapped and has no alias", city, airport.IATA)})
+		case state != airportState:
+			r.add(MappingIssue{Severity: MappingSeverityWarning, Kind: MappingIssueMissingAlias, Source: cityStateMapPath, City: city,
+				States: []string{state, airportState}, Message: fmt.Sprintf("airport city %q (%s) maps to %q but the airport is in %q", city, airport.IATA, state, airportState)})
+		}
+	}
+}
+
+// reports unknown states in the regions, states in more than one region and states in none
+func (r *MappingValidationReport) checkRegions(regions []models.Region, source string) {
+	regionsOfState := make(map[string][]string)
+	for _, region := range regions {
+		for _, name := range region.States {
+			state, ok := GetStateRegistry().Resolve(name)
+			if !ok {
+				r.add(MappingIssue{Severity: MappingSeverityError, Kind: MappingIssueUnknownState, Source: source,
+					States: []string{name}, Message: fmt.Sprintf("region %q lists %q, which is not an Indian state or union territory", region.Name, name)})
+				continue
+			}
+			regionsOfState[state.Name] = append(regionsOfState[state.Name], region.Name)
+		}
+	}
+
+	for _, state := range GetStateRegistry().GetStateNames() {
+		listed := regionsOfState[state]
+		switch {
+		case len(listed) == 0:
+			r.add(MappingIssue{Severity: MappingSeverityWarning, Kind: MappingIssueRegion, Source: source, States: []string{state},
+				Message: fmt.Sprintf("%q is not in any region, it only counts towards the country total", state)})
+		case len(listed) > 1:
+			r.add(MappingIssue{Severity: MappingSeverityError, Kind: MappingIssueRegion, Source: source, States: []string{state},
+				Message: fmt.Sprintf("%q is in regions %s, only %q is used", state, strings.Join(quoteAll(listed), " and "), listed[0])})
+		}
+	}
+}
+
+func (r *MappingValidationReport) add(issue MappingIssue) {
+	if issue.Severity == MappingSeverityError {
+		r.Errors++
+	} else {
+		r.Warnings++
+	}
+	r.Issues = append(r.Issues, issue)
+}
+
+func uniqueSorted(values []string) []string {
+	set := make(map[string]bool, len(values))
+	for _, value := range values {
+		set[value] = true
+	}
+	unique := make([]string, 0, len(set))
+	for value := range set {
+		unique = append(unique, value)
+	}
+	sort.Strings(unique)
+	return unique
+}
+
+func quoteAll(values []string) []string {
+	quoted := make([]string, len(values))
+	for i, value := range values {
+		quoted[i] = fmt.Sprintf("%q", value)
+	}
+	return quoted
+}
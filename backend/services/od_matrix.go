@@ -0,0 +1,90 @@
+package services
+
+import (
+	"sort"
+)
+
+// flights from one state to another - the same state on both ends for intra-state flights
+type ODEdge struct {
+	Source      string         `json:"source"`
+	Destination string         `json:"destination"`
+	Flights     int            `json:"flights"`
+	Airlines    map[string]int `json:"airlines"`     // flights per airline
+	MedianPrice float64        `json:"median_price"` // over the flights with a price
+}
+
+// state-to-state flight counts, as a dense matrix and as a sparse list of the non-zero pairs
+type ODMatrix struct {
+	States          []string     `json:"states"` // row and column order of the matrix, sorted by name
+	Matrix          [][]int      `json:"matrix"` // matrix[i][j] = flights from states[i] to states[j]
+	Edges           []ODEdge     `json:"edges"`  // most flights first
+	TotalFlights    int          `json:"total_flights"`
+	UnplacedFlights int          `json:"unplaced_flights"` // flights matching the filter with an end outside the known states
+	Filter          FlightFilter `json:"filter"`
+}
+
+// builds the origin-destination matrix between states for the flights matching the filter
+// only states with at least one flight get a row and a column
+func (sa *StateAggregator) BuildODMatrix(filter FlightFilter) ODMatrix {
+	type pair struct{ source, destination string }
+	edges := make(map[pair]*ODEdge)
+	prices := make(map[pair][]float64)
+	result := ODMatrix{Filter: filter, States: []string{}, Matrix: [][]int{}, Edges: []ODEdge{}}
+
+	flights := sa.dataService.GetAllFlights()
+	for i := range flights {
+		flight := &flights[i]
+		if !filter.matches(flight) {
+			continue
+		}
+		sourceState, sourceOk, destState, destOk := sa.mapper.resolveFlightStates(flight)
+		if !sourceOk || !destOk {
+			result.UnplacedFlights++
+			continue
+		}
+
+		key := pair{sourceState, destState}
+		edge, exists := edges[key]
+		if !exists {
+			edge = &ODEdge{Source: sourceState, Destination: destState, Airlines: make(map[string]int)}
+			edges[key] = edge
+		}
+		edge.Flights++
+		edge.Airlines[flight.Airline]++
+		if flight.Price > 0 {
+			prices[key] = append(prices[key], flight.Price)
+		}
+		result.TotalFlights++
+	}
+
+	index := make(map[string]int)
+	for key := range edges {
+		index[key.source] = 0
+		index[key.destination] = 0
+	}
+	for state := range index {
+		result.States = append(result.States, state)
+	}
+	sort.Strings(result.States)
+	for i, state := range result.States {
+		index[state] = i
+		result.Matrix = append(result.Matrix, make([]int, len(result.States)))
+	}
+
+	for key, edge := range edges {
+		edge.MedianPrice = median(prices[key])
+		result.Matrix[index[key.source]][index[key.destination]] = edge.Flights
+		result.Edges = append(result.Edges, *edge)
+	}
+	sort.Slice(result.Edges, func(i, j int) bool {
+		a, b := result.Edges[i], result.Edges[j]
+		if a.Flights != b.Flights {
+			return a.Flights > b.Flights
+		}
+		if a.Source != b.Source {
+			return a.Source < b.Source
+		}
+		return a.Destination < b.Destination
+	})
+	return result
+}
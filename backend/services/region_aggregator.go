@@ -0,0 +1,197 @@
+package services
+
+import (
+	"strings"
+
+	"flight-dashboard-backend/models"
+)
+
+// name and slug of the top of the hierarchy - every region rolls up into it
+const (
+	countryName = "India"
+	countrySlug = "india"
+)
+
+// flight counts of one state in a region, or of one region in the country
+type RegionMember struct {
+	Name            string `json:"name"`
+	Slug            string `json:"slug"`
+	TotalFlights    int    `json:"total_flights"`
+	IncomingFlights int    `json:"incoming_flights"`
+	OutgoingFlights int    `json:"outgoing_flights"`
+	IntraFlights    int    `json:"intra_flights"` // both ends in the state, or in the region
+	TransitFlights  int    `json:"transit_flights"`
+	UniqueRoutes    int    `json:"unique_routes"`
+}
+
+// flights of a region (or the whole country) counted once each - a flight between two states
+// of the same region is an intra-region flight, not an incoming plus an outgoing one
+type RegionAggregation struct {
+	Region             string         `json:"region"`
+	Slug               string         `json:"slug"`
+	TotalFlights       int            `json:"total_flights"`        // distinct flights starting or ending in the region - incoming + outgoing + intra-region
+	IncomingFlights    int            `json:"incoming_flights"`     // from outside the region into it
+	OutgoingFlights    int            `json:"outgoing_flights"`     // from the region to outside it
+	IntraRegionFlights int            `json:"intra_region_flights"` // both ends in the region
+	TransitFlights     int            `json:"transit_flights"`      // stopping in the region with both ends outside, not part of the total
+	UniqueRoutes       int            `json:"unique_routes"`
+	Airlines           map[string]int `json:"airlines"`
+	RouteDetails       map[string]int `json:"route_details"`
+	States             []RegionMember `json:"states,omitempty"`  // per-state breakdown of a region
+	Regions            []RegionMember `json:"regions,omitempty"` // per-region breakdown of the country
+}
+
+func newRegionAggregation(name, slug string) *RegionAggregation {
+	return &RegionAggregation{
+		Region:       name,
+		Slug:         slug,
+		Airlines:     make(map[string]int),
+		RouteDetails: make(map[string]int),
+	}
+}
+
+// counts one flight that starts and/or ends in the region
+func (ra *RegionAggregation) addFlight(flight *models.Flight, starts, ends bool) {
+	switch {
+	case starts && ends:
+		ra.IntraRegionFlights++
+	case starts:
+		ra.OutgoingFlights++
+	default:
+		ra.IncomingFlights++
+	}
+	ra.TotalFlights++
+	ra.Airlines[flight.Airline]++
+	ra.RouteDetails[strings.ToLower(flight.Source+"->"+flight.Destination)]++
+}
+
+// builds the region and country rollups for the given flights without touching the stored ones
+// the per-state breakdown comes from the state aggregations built from the same flights
+func (sa *StateAggregator) buildRegionAggregations(flights []models.Flight, states map[string]*StateAggregation) (map[string]*RegionAggregation, *RegionAggregation) {
+	registry := GetRegionRegistry()
+	regions := make(map[string]*RegionAggregation)
+	for _, region := range registry.GetAllRegions() {
+		regions[region.Slug] = newRegionAggregation(region.Name, region.Slug)
+	}
+	country := newRegionAggregation(countryName, countrySlug)
+
+	for _, flight := range flights {
+		sourceState, sourceOk, destState, destOk := sa.mapper.resolveFlightStates(&flight)
+
+		// the country sees every flight with at least one end in a known state
+		if sourceOk || destOk {
+			country.addFlight(&flight, sourceOk, destOk)
+		}
+
+		sourceRegion, destRegion := "", ""
+		if region, ok := registry.RegionOf(sourceState); sourceOk && ok {
+			sourceRegion = region.Slug
+		}
+		if region, ok := registry.RegionOf(destState); destOk && ok {
+			destRegion = region.Slug
+		}
+		if sourceRegion != "" {
+			regions[sourceRegion].addFlight(&flight, true, sourceRegion == destRegion)
+		}
+		if destRegion != "" && destRegion != sourceRegion {
+			regions[destRegion].addFlight(&flight, false, true)
+		}
+
+		// transit is credited once per region, and never to a region the flight starts or ends in
+		credited := map[string]bool{sourceRegion: true, destRegion: true}
+		countryTransit := false
+		for _, state := range transitStates(&flight, sourceState, destState) {
+			countryTransit = true
+			region, ok := registry.RegionOf(state)
+			if !ok || credited[region.Slug] {
+				continue
+			}
+			credited[region.Slug] = true
+			regions[region.Slug].TransitFlights++
+		}
+		if countryTransit && !sourceOk && !destOk {
+			country.TransitFlights++
+		}
+	}
+
+	for _, region := range registry.GetAllRegions() {
+		agg := regions[region.Slug]
+		agg.UniqueRoutes = len(agg.RouteDetails)
+		agg.States = make([]RegionMember, 0, len(region.States))
+		for _, name := range region.States {
+			agg.States = append(agg.States, stateMember(name, states[name]))
+		}
+		country.Regions = append(country.Regions, RegionMember{
+			Name:            agg.Region,
+			Slug:            agg.Slug,
+			TotalFlights:    agg.TotalFlights,
+			IncomingFlights: agg.IncomingFlights,
+			OutgoingFlights: agg.OutgoingFlights,
+			IntraFlights:    agg.IntraRegionFlights,
+			TransitFlights:  agg.TransitFlights,
+			UniqueRoutes:    agg.UniqueRoutes,
+		})
+	}
+	country.UniqueRoutes = len(country.RouteDetails)
+
+	return regions, country
+}
+
+// the breakdown entry of a state, zeros when it has no flights
+func stateMember(name string, agg *StateAggregation) RegionMember {
+	member := RegionMember{Name: name}
+	if state, ok := GetStateRegistry().Resolve(name); ok {
+		member.Slug = state.Slug
+	}
+	if agg != nil {
+		member.TotalFlights = agg.TotalFlights
+		member.IncomingFlights = agg.IncomingFlights
+		member.OutgoingFlights = agg.OutgoingFlights
+		member.IntraFlights = agg.IntraStateFlights
+		member.TransitFlights = agg.TransitFlights
+		member.UniqueRoutes = agg.UniqueRoutes
+	}
+	return member
+}
+
+// returns the aggregation of one region by name or slug, and a bool to check if the region exists
+func (sa *StateAggregator) GetAggregationForRegion(name string) (*RegionAggregation, bool) {
+	region, ok := GetRegionRegistry().Resolve(name)
+	if !ok {
+		return nil, false
+	}
+
+	sa.mutex.RLock()
+	defer sa.mutex.RUnlock()
+	if agg, exists := sa.regions[region.Slug]; exists {
+		return agg, true
+	}
+	return newRegionAggregation(region.Name, region.Slug), true
+}
+
+// returns the aggregations of every region, in the order of the regions file
+func (sa *StateAggregator) GetAllRegionAggregations() []*RegionAggregation {
+	regions := GetRegionRegistry().GetAllRegions()
+
+	sa.mutex.RLock()
+	defer sa.mutex.RUnlock()
+	result := make([]*RegionAggregation, 0, len(regions))
+	for _, region := range regions {
+		if agg, exists := sa.regions[region.Slug]; exists {
+			result = append(result, agg)
+		} else {
+			result = append(result, newRegionAggregation(region.Name, region.Slug))
+		}
+	}
+	return result
+}
+
+// returns the country-wide rollup of all regions
+func (sa *StateAggregator) GetCountryAggregation() *RegionAggregation {
+	sa.mutex.RLock()
+	defer sa.mutex.RUnlock()
+	if sa.country == nil {
+		return newRegionAggregation(countryName, countrySlug)
+	}
+	return sa.country
+}
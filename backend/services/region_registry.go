@@ -0,0 +1,139 @@
+package services
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"sync"
+
+	"flight-dashboard-backend/models"
+)
+
+// regions the dashboard groups states into - edit the file to regroup, no code change needed
+const regionsPath = "data/regions.json"
+
+// used when data/regions.json doesn't exist or can't be read - zones roughly follow the zonal councils
+var defaultRegions = []models.Region{
+	{Name: "North", Slug: "north", States: []string{"Chandigarh", "Delhi", "Haryana", "Himachal Pradesh", "Jammu and Kashmir", "Ladakh", "Punjab", "Rajasthan"}},
+	{Name: "Central", Slug: "central", States: []string{"Chhattisgarh", "Madhya Pradesh", "Uttar Pradesh", "Uttarakhand"}},
+	{Name: "East", Slug: "east", States: []string{"Bihar", "Jharkhand", "Odisha", "West Bengal"}},
+	{Name: "West", Slug: "west", States: []string{"Dadra and Nagar Haveli and Daman and Diu", "Goa", "Gujarat", "Maharashtra"}},
+	{Name: "South", Slug: "south", States: []string{"Andaman and Nicobar Islands", "Andhra Pradesh", "Karnataka", "Kerala", "Lakshadweep", "Puducherry", "Tamil Nadu", "Telangana"}},
+	{Name: "Northeast", Slug: "northeast", States: []string{"Arunachal Pradesh", "Assam", "Manipur", "Meghalaya", "Mizoram", "Nagaland", "Sikkim", "Tripura"}},
+}
+
+// groups the states of the state registry into regions - every state is in at most one region
+type RegionRegistry struct {
+	regions       []models.Region // in the order of the file
+	byKey         map[string]int  // normalized name or slug -> index in regions
+	regionOfState map[string]int  // canonical state name -> index in regions
+}
+
+// global instance - the registry is read once at startup, so there's nothing to lock
+var regionRegistry *RegionRegistry
+var regionRegistryOnce sync.Once
+
+// returns singleton instance of the region registry
+func GetRegionRegistry() *RegionRegistry {
+	regionRegistryOnce.Do(func() {
+		regions, err := readRegions(regionsPath)
+		if err != nil {
+			log.Printf("Could not load regions from %s, using the default regions: %v", regionsPath, err)
+			regions = defaultRegions
+		}
+		regionRegistry = newRegionRegistry(regions)
+		log.Printf("Loaded %d regions", len(regionRegistry.regions))
+	})
+	return regionRegistry
+}
+
+// reads the raw region list from the regions file
+func readRegions(path string) ([]models.Region, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var regions []models.Region
+	if err := json.Unmarshal(data, &regions); err != nil {
+		return nil, err
+	}
+	if len(regions) == 0 {
+		return nil, fmt.Errorf("no regions defined")
+	}
+	return regions, nil
+}
+
+// builds the registry - state names are canonicalized, unknown states and states already in a region are skipped
+func newRegionRegistry(regions []models.Region) *RegionRegistry {
+	registry := &RegionRegistry{byKey: make(map[string]int), regionOfState: make(map[string]int)}
+
+	for _, region := range regions {
+		region.Name = strings.TrimSpace(region.Name)
+		if region.Name == "" {
+			log.Println("Skipping region without a name")
+			continue
+		}
+		if region.Slug == "" {
+			region.Slug = strings.ReplaceAll(stateKey(region.Name), " ", "-")
+		}
+		if _, exists := registry.byKey[stateKey(region.Name)]; exists {
+			log.Printf("Duplicate region %q, keeping the first one", region.Name)
+			continue
+		}
+
+		idx := len(registry.regions)
+		states := make([]string, 0, len(region.States))
+		for _, name := range region.States {
+			state, ok := GetStateRegistry().Resolve(name)
+			if !ok {
+				log.Printf("Region %s lists unknown state %q, skipping it", region.Name, name)
+				continue
+			}
+			if other, taken := registry.regionOfState[state.Name]; taken {
+				log.Printf("State %s is in both %s and %s, keeping it in %s", state.Name, registry.regions[other].Name, region.Name, registry.regions[other].Name)
+				continue
+			}
+			registry.regionOfState[state.Name] = idx
+			states = append(states, state.Name)
+		}
+		region.States = states
+
+		registry.regions = append(registry.regions, region)
+		registry.byKey[stateKey(region.Name)] = idx
+		registry.byKey[stateKey(region.Slug)] = idx
+	}
+
+	for _, state := range GetStateRegistry().GetStateNames() {
+		if _, assigned := registry.regionOfState[state]; !assigned {
+			log.Printf("Warning: %s is not in any region, it only counts towards the country total", state)
+		}
+	}
+	return registry
+}
+
+// finds a region by name or slug, case doesn't matter
+func (rr *RegionRegistry) Resolve(name string) (models.Region, bool) {
+	idx, exists := rr.byKey[stateKey(name)]
+	if !exists {
+		return models.Region{}, false
+	}
+	return rr.regions[idx], true
+}
+
+// returns the region a state belongs to - the state can be given any way the state registry understands
+func (rr *RegionRegistry) RegionOf(state string) (models.Region, bool) {
+	idx, exists := rr.regionOfState[GetStateRegistry().CanonicalName(state)]
+	if !exists {
+		return models.Region{}, false
+	}
+	return rr.regions[idx], true
+}
+
+// returns every region in the order of the regions file
+func (rr *RegionRegistry) GetAllRegions() []models.Region {
+	result := make([]models.Region, len(rr.regions))
+	copy(result, rr.regions)
+	return result
+}
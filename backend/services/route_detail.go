@@ -0,0 +1,143 @@
+package services
+
+import (
+	"sort"
+	"strings"
+
+	"flight-dashboard-backend/models"
+)
+
+// class key of flights from datasets without a class column
+const unspecifiedClass = "unspecified"
+
+// one airline serving a route
+type RouteAirline struct {
+	Airline     string  `json:"airline"`
+	Flights     int     `json:"flights"`
+	MedianPrice float64 `json:"median_price"` // over the flights with a price
+}
+
+// everything about the flights from one city to another - cities as the city mapping resolves them
+type RouteDetail struct {
+	Source           string                       `json:"source"`
+	Destination      string                       `json:"destination"`
+	SourceState      string                       `json:"source_state,omitempty"` // empty for cities abroad or unmapped ones
+	DestinationState string                       `json:"destination_state,omitempty"`
+	TotalFlights     int                          `json:"total_flights"`
+	Airlines         []RouteAirline               `json:"airlines"` // most flights first
+	Fares            DistributionStats            `json:"fares"`
+	FaresByClass     map[string]DistributionStats `json:"fares_by_class"` // lower-case class, "unspecified" without a class column
+	Durations        DistributionStats            `json:"durations"`      // hours
+	DistanceKm       float64                      `json:"distance_km"`    // 0 when a city has no coordinates
+	ExpectedHours    float64                      `json:"expected_hours"`
+	Efficiency       float64                      `json:"efficiency"`      // median duration / expected hours, as in the route durations
+	Stops            map[int]int                  `json:"stops"`           // flights per number of stops
+	DepartureHours   [24]int                      `json:"departure_hours"` // flights per hour of departure in IST, flights without a time are left out
+	Flights          []models.Flight              `json:"-"`               // in dataset order, paged by the handler
+}
+
+// collects every flight from source to destination - either name can be an alias, a spelling or an airport code
+// the bool is false when no flight flies the route
+func (sa *StateAggregator) BuildRouteDetail(source, destination string) (RouteDetail, bool) {
+	source = sa.mapper.canonicalCity(source)
+	destination = sa.mapper.canonicalCity(destination)
+	detail := RouteDetail{
+		Source:       source,
+		Destination:  destination,
+		Airlines:     []RouteAirline{},
+		FaresByClass: make(map[string]DistributionStats),
+		Stops:        make(map[int]int),
+		Flights:      []models.Flight{},
+	}
+
+	// raw names repeat on every row, so each one is resolved once
+	canonical := make(map[string]string)
+	resolve := func(city string) string {
+		if name, seen := canonical[city]; seen {
+			return name
+		}
+		canonical[city] = sa.mapper.canonicalCity(city)
+		return canonical[city]
+	}
+
+	airlineFlights := make(map[string]int)
+	airlinePrices := make(map[string][]float64)
+	classPrices := make(map[string][]float64)
+	var prices, hours []float64
+	for _, flight := range sa.dataService.GetAllFlights() {
+		if resolve(flight.Source) != source || resolve(flight.Destination) != destination {
+			continue
+		}
+		detail.Flights = append(detail.Flights, flight)
+		airlineFlights[flight.Airline]++
+		detail.Stops[flight.Stops]++
+		if hour, ok := departureHour(&flight); ok {
+			detail.DepartureHours[hour]++
+		}
+		if flight.Price > 0 {
+			class := strings.ToLower(strings.TrimSpace(flight.FlightClass))
+			if class == "" {
+				class = unspecifiedClass
+			}
+			prices = append(prices, flight.Price)
+			airlinePrices[flight.Airline] = append(airlinePrices[flight.Airline], flight.Price)
+			classPrices[class] = append(classPrices[class], flight.Price)
+		}
+		if flightHours := flightHours(&flight); flightHours > 0 {
+			hours = append(hours, flightHours)
+		}
+	}
+	if len(detail.Flights) == 0 {
+		return detail, false
+	}
+
+	detail.TotalFlights = len(detail.Flights)
+	sourceState, sourceOk, destState, destOk := sa.mapper.resolveFlightStates(&detail.Flights[0])
+	if sourceOk {
+		detail.SourceState = sourceState
+	}
+	if destOk {
+		detail.DestinationState = destState
+	}
+
+	for airline, flights := range airlineFlights {
+		detail.Airlines = append(detail.Airlines, RouteAirline{Airline: airline, Flights: flights, MedianPrice: median(airlinePrices[airline])})
+	}
+	sort.Slice(detail.Airlines, func(i, j int) bool {
+		if detail.Airlines[i].Flights != detail.Airlines[j].Flights {
+			return detail.Airlines[i].Flights > detail.Airlines[j].Flights
+		}
+		return detail.Airlines[i].Airline < detail.Airlines[j].Airline
+	})
+
+	detail.Fares = distributionStats(prices)
+	for class, classFares := range classPrices {
+		detail.FaresByClass[class] = distributionStats(classFares)
+	}
+	detail.Durations = distributionStats(hours)
+
+	sourceAirport, sourceLocated := sa.mapper.locateCity(detail.Flights[0].Source)
+	destAirport, destLocated := sa.mapper.locateCity(detail.Flights[0].Destination)
+	if sourceLocated && destLocated {
+		distance := greatCircleKm(sourceAirport.Latitude, sourceAirport.Longitude, destAirport.Latitude, destAirport.Longitude)
+		if distance > 0 {
+			expected := expectedBlockTime(distance)
+			detail.DistanceKm = roundTo(distance, 1)
+			detail.ExpectedHours = roundTo(expected, 2)
+			detail.Efficiency = roundTo(detail.Durations.Median/expected, 2)
+		}
+	}
+	return detail, true
+}
+
+// hour of day (IST) a flight departs at - from the parsed departure, or the raw time when the date didn't parse
+func departureHour(flight *models.Flight) (int, bool) {
+	if !flight.Departure.IsZero() {
+		return flight.Departure.Hour(), true
+	}
+	clock, ok := parseClock(flight.DepartureTime)
+	if !ok {
+		return 0, false
+	}
+	return int(clock.Hours()), true
+}
@@ -4,23 +4,41 @@ import (
 	"log"
 	"strings"
 	"sync"
+
+	"flight-dashboard-backend/models"
 )
 
 type StateAggregation struct {
-	StateName       string         `json:"state_name"`       
-	TotalFlights    int            `json:"total_flights"`    
-	IncomingFlights int            `json:"incoming_flights"` 
-	OutgoingFlights int            `json:"outgoing_flights"` 
-	UniqueRoutes    int            `json:"unique_routes"`    
-	Airlines        map[string]int `json:"airlines"`         
-	RouteDetails    map[string]int `json:"route_details"`    
+	StateName                 string         `json:"state_name"`
+	TotalFlights              int            `json:"total_flights"`
+	IncomingFlights           int            `json:"incoming_flights"`
+	OutgoingFlights           int            `json:"outgoing_flights"`
+	IntraStateFlights         int            `json:"intra_state_flights"`                  // both ends in the state, counted once
+	TransitFlights            int            `json:"transit_flights"`                      // flights stopping here on the way elsewhere, not part of the total
+	InternationalIncoming     int            `json:"international_incoming"`               // incoming flights from abroad, part of the incoming count
+	InternationalOutgoing     int            `json:"international_outgoing"`               // outgoing flights abroad, part of the outgoing count
+	InternationalDestinations map[string]int `json:"international_destinations,omitempty"` // foreign city -> outgoing flights to it
+	UniqueRoutes              int            `json:"unique_routes"`
+	Airlines                  map[string]int `json:"airlines"`
+	RouteDetails              map[string]int `json:"route_details"`
+
+	airlineFlights map[string]*airlineFlights // per airline split of the counts above, for the ranked breakdown
 }
 
 type StateAggregator struct {
-	aggregations map[string]*StateAggregation 
-	mutex        sync.RWMutex                 
-	dataService  *FlightDataService           
-	mapper       *CityStateMapper             
+	aggregations map[string]*StateAggregation
+	regions      map[string]*RegionAggregation              // by region slug, rolled up from the same flights
+	country      *RegionAggregation                         // the whole country, rolled up from the regions
+	districts    map[string]map[string]*DistrictAggregation // canonical state -> district -> aggregation
+	undistricted map[string]int                             // canonical state -> flights without a district
+	scopes       map[string]int                             // flights per domestic/international classification
+	fares        *FareIndex                                 // fare distributions per state, route and airline
+	durations    *DurationIndex                             // duration distributions and efficiency per state and route
+	airlines     map[string]*AirlineAggregation             // by airline slug
+	cities       map[string]*CityAggregation                // by city as the city mapping resolves it
+	mutex        sync.RWMutex
+	dataService  *FlightDataService
+	mapper       *CityStateMapper
 }
 
 // global instance of the state aggregator 
@@ -40,33 +58,85 @@ func GetStateAggregator() *StateAggregator {
 	return stateAggregator
 }
 
+// recomputes the aggregations from the currently loaded flights and swaps them in
 func (sa *StateAggregator) ComputeAggregations() {
+	// getting all flights - done before locking so we never hold both locks here
+	flights := sa.dataService.GetAllFlights()
+	set := sa.buildAggregationSet(flights)
+	aggregations := set.states
+
 	sa.mutex.Lock()
-	defer sa.mutex.Unlock()
+	sa.setAggregations(set)
+	sa.mutex.Unlock()
 
-	// getting all flights
-	flights := sa.dataService.GetAllFlights()
+	//log.Printf("Computed state-wise aggregations for %d states", len(aggregations))
+
+	// logging some summary information
+	for state, agg := range aggregations {
+		log.Printf("State: %s - Total: %d, Incoming: %d, Outgoing: %d, Transit: %d, Unique Routes: %d, Airlines: %d",
+			state, agg.TotalFlights, agg.IncomingFlights, agg.OutgoingFlights, agg.TransitFlights, agg.UniqueRoutes, len(agg.Airlines))
+	}
+}
 
+// everything the aggregator derives from one set of flights - always swapped in together
+type aggregationSet struct {
+	states       map[string]*StateAggregation
+	regions      map[string]*RegionAggregation
+	country      *RegionAggregation
+	districts    map[string]map[string]*DistrictAggregation
+	undistricted map[string]int // per state, flights with an end in it that has no district
+	scopes       map[string]int // flights per classification - domestic, international, foreign, unknown
+	fares        *FareIndex
+	durations    *DurationIndex
+	airlines     map[string]*AirlineAggregation
+	cities       map[string]*CityAggregation
+}
+
+// builds the state, region, district, city, airline, fare and duration aggregations for the given flights without touching the stored ones
+func (sa *StateAggregator) buildAggregationSet(flights []models.Flight) aggregationSet {
+	set := aggregationSet{states: sa.buildAggregations(flights)}
+	set.regions, set.country = sa.buildRegionAggregations(flights, set.states)
+	set.districts, set.undistricted = sa.buildDistrictAggregations(flights)
+	set.scopes = make(map[string]int)
+	for i := range flights {
+		set.scopes[sa.mapper.ClassifyFlight(&flights[i])]++
+	}
+	set.fares = sa.buildFareIndex(flights)
+	set.durations = sa.buildDurationIndex(flights)
+	set.airlines = sa.buildAirlineAggregations(flights, set.country.TotalFlights)
+	set.cities = sa.buildCityAggregations(flights)
+	return set
+}
+
+// swaps in a freshly built set - the caller holds sa.mutex for writing
+func (sa *StateAggregator) setAggregations(set aggregationSet) {
+	sa.aggregations = set.states
+	sa.regions = set.regions
+	sa.country = set.country
+	sa.districts = set.districts
+	sa.undistricted = set.undistricted
+	sa.scopes = set.scopes
+	sa.fares = set.fares
+	sa.durations = set.durations
+	sa.airlines = set.airlines
+	sa.cities = set.cities
+}
+
+// builds state-wise aggregations for the given flights without touching the stored ones
+func (sa *StateAggregator) buildAggregations(flights []models.Flight) map[string]*StateAggregation {
 	// initializing aggregation map
 	aggregations := make(map[string]*StateAggregation)
 
 	// iterating through all flights to compute aggregations
 	for _, flight := range flights {
 		// getting states for source and destination
-		sourceState, sourceOk := sa.mapper.GetStateForCity(flight.Source)
-		destState, destOk := sa.mapper.GetStateForCity(flight.Destination)
+		sourceState, sourceOk, destState, destOk := sa.mapper.resolveFlightStates(&flight)
 
-		// If not found with original name, try with normalized name
-		if !sourceOk {
-			sourceState, sourceOk = sa.mapper.GetStateForCity(normalizeCityNameForMapping(flight.Source))
-		}
-		if !destOk {
-			destState, destOk = sa.mapper.GetStateForCity(normalizeCityNameForMapping(flight.Destination))
-		}
+		// a flight is counted once for every state it touches - Mumbai->Pune is one intra-state flight for Maharashtra
+		intraState := sourceOk && destOk && sourceState == destState
 
-		// Process source state (outgoing flights)
+		// Process source state (outgoing flights, or the intra-state ones)
 		if sourceOk {
-			sourceState = strings.Title(strings.ToLower(sourceState)) // Capitalize properly
 			if _, exists := aggregations[sourceState]; !exists {
 				aggregations[sourceState] = &StateAggregation{
 					StateName:       sourceState,
@@ -80,7 +150,13 @@ func (sa *StateAggregator) ComputeAggregations() {
 			}
 
 			agg := aggregations[sourceState]
-			agg.OutgoingFlights++
+			if intraState {
+				agg.IntraStateFlights++
+				agg.addAirlineFlight(&flight, directionIntra)
+			} else {
+				agg.OutgoingFlights++
+				agg.addAirlineFlight(&flight, directionOutgoing)
+			}
 			agg.TotalFlights++
 			agg.Airlines[flight.Airline]++
 
@@ -89,9 +165,8 @@ func (sa *StateAggregator) ComputeAggregations() {
 			agg.RouteDetails[routeKey]++
 		}
 
-		// Process destination state (incoming flights)
-		if destOk {
-			destState = strings.Title(strings.ToLower(destState)) 
+		// Process destination state (incoming flights) - intra-state flights were counted above
+		if destOk && !intraState {
 			if _, exists := aggregations[destState]; !exists {
 				aggregations[destState] = &StateAggregation{
 					StateName:       destState,
@@ -108,12 +183,40 @@ func (sa *StateAggregator) ComputeAggregations() {
 			agg.IncomingFlights++
 			agg.TotalFlights++
 			agg.Airlines[flight.Airline]++
+			agg.addAirlineFlight(&flight, directionIncoming)
 
 			// adding route detail 
 			routeKey := strings.ToLower(flight.Source + "->" + flight.Destination)
 			agg.RouteDetails[routeKey]++
 		}
 
+		// flights between a state and a city abroad
+		if sourceOk && !destOk {
+			if foreign, ok := sa.mapper.lookupForeignCity(flight.Destination); ok {
+				agg := aggregations[sourceState]
+				agg.InternationalOutgoing++
+				if agg.InternationalDestinations == nil {
+					agg.InternationalDestinations = make(map[string]int)
+				}
+				agg.InternationalDestinations[foreign.City]++
+			}
+		}
+		if destOk && !sourceOk && sa.mapper.isForeignCity(flight.Source) {
+			aggregations[destState].InternationalIncoming++
+		}
+
+		// Process intermediate stops (transit flights) - each state counted once per flight
+		for _, transitState := range transitStates(&flight, sourceState, destState) {
+			if _, exists := aggregations[transitState]; !exists {
+				aggregations[transitState] = &StateAggregation{
+					StateName:    transitState,
+					Airlines:     make(map[string]int),
+					RouteDetails: make(map[string]int),
+				}
+			}
+			aggregations[transitState].TransitFlights++
+		}
+
 	}
 
 	// calculating unique routes for each state
@@ -121,46 +224,69 @@ func (sa *StateAggregator) ComputeAggregations() {
 		agg.UniqueRoutes = len(agg.RouteDetails)
 	}
 
-	sa.aggregations = aggregations
+	return aggregations
+}
 
-	//log.Printf("Computed state-wise aggregations for %d states", len(aggregations))
+// returns the states a flight stops in on the way, each once and without the excluded ones (its own ends)
+func transitStates(flight *models.Flight, exclude ...string) []string {
+	credited := make(map[string]bool, len(exclude))
+	for _, state := range exclude {
+		credited[state] = true
+	}
 
-	// logging some summary information
-	for state, agg := range aggregations {
-		log.Printf("State: %s - Total: %d, Incoming: %d, Outgoing: %d, Unique Routes: %d, Airlines: %d",
-			state, agg.TotalFlights, agg.IncomingFlights, agg.OutgoingFlights, agg.UniqueRoutes, len(agg.Airlines))
+	var states []string
+	for _, code := range stopoverCodes(flight) {
+		airport, ok := GetAirportRegistry().GetAirport(code)
+		if !ok || airport.State == "" {
+			continue
+		}
+		state := GetStateRegistry().CanonicalName(airport.State)
+		if credited[state] {
+			continue
+		}
+		credited[state] = true
+		states = append(states, state)
+	}
+	return states
+}
+
+// what the flight counts of a state, district or region mean - sent along with the counts so published numbers reconcile
+// every flight is counted once per area it touches: total = incoming + outgoing + intra
+func FlightCountDefinitions(level string) map[string]string {
+	intraKey := "intra" + strings.ToUpper(level[:1]) + level[1:] + "Flights"
+	return map[string]string{
+		"totalFlights":    "distinct flights starting or ending in the " + level + " - incomingFlights + outgoingFlights + " + intraKey,
+		"incomingFlights": "flights arriving in the " + level + " from outside it, including from abroad",
+		"outgoingFlights": "flights leaving the " + level + " for somewhere outside it, including abroad",
+		intraKey:          "flights with both ends in the " + level + ", counted once",
+		"transitFlights":  "flights stopping in the " + level + " with both ends outside it, not part of totalFlights",
 	}
 }
 
 // returns the aggregation and a bool to check if it exists
+// the state can be given by name, slug, ISO code or historic alias - resolved through the state registry
 func (sa *StateAggregator) GetAggregationForState(stateName string) (*StateAggregation, bool) {
 	sa.mutex.RLock()
 	defer sa.mutex.RUnlock()
 
-	// normalize state name for lookup - handles different ways the name might be formatted
-	normalizedState := strings.Title(strings.ToLower(stateName))
-
-	agg, exists := sa.aggregations[normalizedState]
+	canonicalState := GetStateRegistry().CanonicalName(stateName)
+	agg, exists := sa.aggregations[canonicalState]
 	if exists {
 		return agg, true
 	}
 
-	// check if this is a valid Indian state name, even if no flight data exists
-	allStates := sa.GetAllIndianStates()
-	for _, validState := range allStates {
-		if strings.EqualFold(validState, stateName) || strings.EqualFold(strings.Title(strings.ToLower(validState)), normalizedState) {
-			// returns a default aggregation with 0 values for valid states without data
-			defaultAgg := &StateAggregation{
-				StateName:       validState,
-				TotalFlights:    0,
-				IncomingFlights: 0,
-				OutgoingFlights: 0,
-				UniqueRoutes:    0,
-				Airlines:        make(map[string]int),
-				RouteDetails:    make(map[string]int),
-			}
-			return defaultAgg, true
+	// returns a default aggregation with 0 values for valid states without data
+	if state, ok := GetStateRegistry().Resolve(stateName); ok {
+		defaultAgg := &StateAggregation{
+			StateName:       state.Name,
+			TotalFlights:    0,
+			IncomingFlights: 0,
+			OutgoingFlights: 0,
+			UniqueRoutes:    0,
+			Airlines:        make(map[string]int),
+			RouteDetails:    make(map[string]int),
 		}
+		return defaultAgg, true
 	}
 
 	return nil, false
@@ -187,17 +313,7 @@ func (sa *StateAggregator) RefreshAggregations() {
 
 // returns a list of all Indian states - needed for the state dropdown
 func (sa *StateAggregator) GetAllIndianStates() []string {
-	allStates := []string{
-		"Andhra Pradesh", "Arunachal Pradesh", "Assam", "Bihar", "Chhattisgarh",
-		"Goa", "Gujarat", "Haryana", "Himachal Pradesh", "Jharkhand",
-		"Karnataka", "Kerala", "Madhya Pradesh", "Maharashtra", "Manipur",
-		"Meghalaya", "Mizoram", "Nagaland", "Odisha", "Punjab",
-		"Rajasthan", "Sikkim", "Tamil Nadu", "Telangana", "Tripura",
-		"Uttar Pradesh", "Uttarakhand", "West Bengal",
-		"Delhi", "Puducherry", "Andaman and Nicobar Islands",
-		"Dadra and Nagar Haveli and Daman and Diu", "Lakshadweep", "Ladakh",
-	}
-	return allStates
+	return GetStateRegistry().GetStateNames()
 }
 
 // GetStatesList returns a list of all states that have flight data
@@ -212,21 +328,6 @@ func (sa *StateAggregator) GetStatesList() []string {
 	return states
 }
 
-// GetTopAirlinesForState returns the top airlines for a specific state
-func (sa *StateAggregator) GetTopAirlinesForState(stateName string, limit int) map[string]int {
-	agg, exists := sa.GetAggregationForState(stateName)
-	if !exists {
-		return nil
-	}
-
-	// returning a copy of the airlines map
-	result := make(map[string]int)
-	for k, v := range agg.Airlines {
-		result[k] = v
-	}
-	return result
-}
-
 // GetTotalFlightsForState returns the total number of flights for a specific state
 func (sa *StateAggregator) GetTotalFlightsForState(stateName string) int {
 	agg, exists := sa.GetAggregationForState(stateName)
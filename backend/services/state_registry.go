@@ -0,0 +1,142 @@
+package services
+
+import (
+	"strings"
+	"sync"
+
+	"flight-dashboard-backend/models"
+)
+
+// kinds of entries in the state registry
+const (
+	StateTypeState          = "state"
+	StateTypeUnionTerritory = "union_territory"
+)
+
+// every Indian state and union territory - names and slugs match frontend/state-list.json and the topojson
+// old ISO codes (CT, OR, TG, UT, DN, DD) are kept as aliases so older data still resolves
+var indianStates = []models.IndianState{
+	{Name: "Andaman and Nicobar Islands", Code: "IN-AN", Slug: "andaman-and-nicobar-islands", Type: StateTypeUnionTerritory, Capital: "Port Blair", Aliases: []string{"Andaman & Nicobar Islands", "Andaman and Nicobar", "A&N Islands"}},
+	{Name: "Andhra Pradesh", Code: "IN-AP", Slug: "andhra-pradesh", Type: StateTypeState, Capital: "Amaravati"},
+	{Name: "Arunachal Pradesh", Code: "IN-AR", Slug: "arunachal-pradesh", Type: StateTypeState, Capital: "Itanagar"},
+	{Name: "Assam", Code: "IN-AS", Slug: "assam", Type: StateTypeState, Capital: "Dispur"},
+	{Name: "Bihar", Code: "IN-BR", Slug: "bihar", Type: StateTypeState, Capital: "Patna"},
+	{Name: "Chandigarh", Code: "IN-CH", Slug: "chandigarh", Type: StateTypeUnionTerritory, Capital: "Chandigarh"},
+	{Name: "Chhattisgarh", Code: "IN-CG", Slug: "chhattisgarh", Type: StateTypeState, Capital: "Raipur", Aliases: []string{"Chattisgarh", "IN-CT"}},
+	{Name: "Dadra and Nagar Haveli and Daman and Diu", Code: "IN-DH", Slug: "dnh-and-dd", Type: StateTypeUnionTerritory, Capital: "Daman", Aliases: []string{"Dadra and Nagar Haveli", "Daman and Diu", "DNH and DD", "IN-DN", "IN-DD"}},
+	{Name: "Delhi", Code: "IN-DL", Slug: "delhi", Type: StateTypeUnionTerritory, Capital: "New Delhi", Aliases: []string{"NCT of Delhi", "National Capital Territory of Delhi"}},
+	{Name: "Goa", Code: "IN-GA", Slug: "goa", Type: StateTypeState, Capital: "Panaji"},
+	{Name: "Gujarat", Code: "IN-GJ", Slug: "gujarat", Type: StateTypeState, Capital: "Gandhinagar"},
+	{Name: "Haryana", Code: "IN-HR", Slug: "haryana", Type: StateTypeState, Capital: "Chandigarh"},
+	{Name: "Himachal Pradesh", Code: "IN-HP", Slug: "himachal-pradesh", Type: StateTypeState, Capital: "Shimla"},
+	{Name: "Jammu and Kashmir", Code: "IN-JK", Slug: "jammu-and-kashmir", Type: StateTypeUnionTerritory, Capital: "Srinagar", Aliases: []string{"Jammu & Kashmir", "J&K"}},
+	{Name: "Jharkhand", Code: "IN-JH", Slug: "jharkhand", Type: StateTypeState, Capital: "Ranchi"},
+	{Name: "Karnataka", Code: "IN-KA", Slug: "karnataka", Type: StateTypeState, Capital: "Bengaluru", Aliases: []string{"Mysore State"}},
+	{Name: "Kerala", Code: "IN-KL", Slug: "kerala", Type: StateTypeState, Capital: "Thiruvananthapuram"},
+	{Name: "Ladakh", Code: "IN-LA", Slug: "ladakh", Type: StateTypeUnionTerritory, Capital: "Leh"},
+	{Name: "Lakshadweep", Code: "IN-LD", Slug: "lakshadweep", Type: StateTypeUnionTerritory, Capital: "Kavaratti"},
+	{Name: "Madhya Pradesh", Code: "IN-MP", Slug: "madhya-pradesh", Type: StateTypeState, Capital: "Bhopal"},
+	{Name: "Maharashtra", Code: "IN-MH", Slug: "maharashtra", Type: StateTypeState, Capital: "Mumbai"},
+	{Name: "Manipur", Code: "IN-MN", Slug: "manipur", Type: StateTypeState, Capital: "Imphal"},
+	{Name: "Meghalaya", Code: "IN-ML", Slug: "meghalaya", Type: StateTypeState, Capital: "Shillong"},
+	{Name: "Mizoram", Code: "IN-MZ", Slug: "mizoram", Type: StateTypeState, Capital: "Aizawl"},
+	{Name: "Nagaland", Code: "IN-NL", Slug: "nagaland", Type: StateTypeState, Capital: "Kohima"},
+	{Name: "Odisha", Code: "IN-OD", Slug: "odisha", Type: StateTypeState, Capital: "Bhubaneswar", Aliases: []string{"Orissa", "IN-OR"}},
+	{Name: "Puducherry", Code: "IN-PY", Slug: "puducherry", Type: StateTypeUnionTerritory, Capital: "Puducherry", Aliases: []string{"Pondicherry"}},
+	{Name: "Punjab", Code: "IN-PB", Slug: "punjab", Type: StateTypeState, Capital: "Chandigarh"},
+	{Name: "Rajasthan", Code: "IN-RJ", Slug: "rajasthan", Type: StateTypeState, Capital: "Jaipur"},
+	{Name: "Sikkim", Code: "IN-SK", Slug: "sikkim", Type: StateTypeState, Capital: "Gangtok"},
+	{Name: "Tamil Nadu", Code: "IN-TN", Slug: "tamil-nadu", Type: StateTypeState, Capital: "Chennai", Aliases: []string{"Tamilnadu", "Madras State"}},
+	{Name: "Telangana", Code: "IN-TS", Slug: "telangana", Type: StateTypeState, Capital: "Hyderabad", Aliases: []string{"Telengana", "IN-TG"}},
+	{Name: "Tripura", Code: "IN-TR", Slug: "tripura", Type: StateTypeState, Capital: "Agartala"},
+	{Name: "Uttar Pradesh", Code: "IN-UP", Slug: "uttar-pradesh", Type: StateTypeState, Capital: "Lucknow"},
+	{Name: "Uttarakhand", Code: "IN-UK", Slug: "uttarakhand", Type: StateTypeState, Capital: "Dehradun", Aliases: []string{"Uttaranchal", "IN-UT"}},
+	{Name: "West Bengal", Code: "IN-WB", Slug: "west-bengal", Type: StateTypeState, Capital: "Kolkata"},
+}
+
+// resolves any way of writing a state to its registry entry
+type StateRegistry struct {
+	states []models.IndianState
+	byKey  map[string]int // normalized name, slug, code or alias -> index in states
+}
+
+// global instance - the registry never changes, so there's nothing to lock
+var stateRegistry *StateRegistry
+var stateRegistryOnce sync.Once
+
+// returns singleton instance of the state registry
+func GetStateRegistry() *StateRegistry {
+	stateRegistryOnce.Do(func() {
+		stateRegistry = &StateRegistry{states: indianStates, byKey: make(map[string]int)}
+		for i, state := range indianStates {
+			keys := append([]string{state.Name, state.Slug, state.Code}, state.Aliases...)
+			for _, key := range keys {
+				stateRegistry.byKey[stateKey(key)] = i
+				// codes also resolve without the country prefix - "KA", "OR"
+				if code, isCode := strings.CutPrefix(key, "IN-"); isCode {
+					stateRegistry.byKey[stateKey(code)] = i
+				}
+			}
+		}
+	})
+	return stateRegistry
+}
+
+// reduces a state name, slug or code to a lookup key - case, "&", dashes and punctuation don't matter
+// "Dadra And Nagar Haveli...", "dadra-and-nagar-haveli-and-daman-and-diu" and "DNH & DD" all resolve
+func stateKey(name string) string {
+	name = strings.ToLower(strings.ReplaceAll(name, "&", " and "))
+	var key strings.Builder
+	space := false
+	for _, r := range name {
+		if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') {
+			if space && key.Len() > 0 {
+				key.WriteByte(' ')
+			}
+			key.WriteRune(r)
+			space = false
+		} else {
+			space = true
+		}
+	}
+	return key.String()
+}
+
+// finds a state by canonical name, slug, ISO code (with or without "IN-") or historic alias
+func (sr *StateRegistry) Resolve(name string) (models.IndianState, bool) {
+	idx, exists := sr.byKey[stateKey(name)]
+	if !exists {
+		return models.IndianState{}, false
+	}
+	return sr.states[idx], true
+}
+
+// returns the canonical name of a state, or the name as given (trimmed) when the registry doesn't know it
+func (sr *StateRegistry) CanonicalName(name string) string {
+	if state, ok := sr.Resolve(name); ok {
+		return state.Name
+	}
+	return strings.TrimSpace(name)
+}
+
+// returns every state and union territory, sorted by name
+func (sr *StateRegistry) GetAllStates() []models.IndianState {
+	result := make([]models.IndianState, len(sr.states))
+	copy(result, sr.states)
+	return result
+}
+
+// returns the canonical names of every state and union territory, sorted
+func (sr *StateRegistry) GetStateNames() []string {
+	names := make([]string, len(sr.states))
+	for i, state := range sr.states {
+		names[i] = state.Name
+	}
+	return names
+}
+
+// tells if a state name is one of the Indian states or union territories we know
+func isKnownState(state string) bool {
+	_, ok := GetStateRegistry().Resolve(state)
+	return ok
+}
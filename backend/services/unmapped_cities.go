@@ -0,0 +1,133 @@
+package services
+
+import (
+	"sort"
+	"strings"
+
+	"flight-dashboard-backend/models"
+)
+
+// suggestions below this confidence are noise, not worth showing
+const minSuggestionConfidence = 0.5
+
+// a city string from the dataset that didn't resolve to any state
+type UnmappedCity struct {
+	Name          string           `json:"name"`
+	Flights       int              `json:"flights"` // flights with this city on either side
+	AsSource      int              `json:"as_source"`
+	AsDestination int              `json:"as_destination"`
+	Suggestions   []CitySuggestion `json:"suggestions"`
+}
+
+// a city string that only resolved through fuzzy matching - a good candidate for a real alias
+type FuzzyMatchedCity struct {
+	Name    string    `json:"name"`
+	Flights int       `json:"flights"`
+	Match   CityMatch `json:"match"`
+}
+
+// what the mapping loses from the current dataset
+type UnmappedCityReport struct {
+	Threshold       float64            `json:"threshold"`
+	AffectedFlights int                `json:"affected_flights"` // flights where at least one side isn't counted for any state
+	Unmapped        []UnmappedCity     `json:"unmapped"`
+	FuzzyMatched    []FuzzyMatchedCity `json:"fuzzy_matched"`
+}
+
+// checks every source and destination of the flights against the mapping
+// cities are grouped case-insensitively and sorted by flight count, most flights first
+func (csm *CityStateMapper) BuildUnmappedReport(flights []models.Flight) UnmappedCityReport {
+	report := UnmappedCityReport{
+		Threshold:    csm.matchThreshold,
+		Unmapped:     []UnmappedCity{},
+		FuzzyMatched: []FuzzyMatchedCity{},
+	}
+
+	unmapped := make(map[string]*UnmappedCity)
+	fuzzy := make(map[string]*FuzzyMatchedCity)
+	resolved := make(map[string]bool)
+
+	// returns whether the side counts towards a state
+	// newFlight is false when the other side of the same flight already counted this city
+	check := func(city string, isSource bool, newFlight bool) bool {
+		key := strings.ToLower(strings.TrimSpace(city))
+		if resolved[key] {
+			return true
+		}
+		if entry, exists := unmapped[key]; exists {
+			if newFlight {
+				entry.Flights++
+			}
+			if isSource {
+				entry.AsSource++
+			} else {
+				entry.AsDestination++
+			}
+			return false
+		}
+		if entry, exists := fuzzy[key]; exists {
+			if newFlight {
+				entry.Flights++
+			}
+			return true
+		}
+
+		match, ok := csm.ResolveCity(city)
+		switch {
+		case !ok && csm.isForeignCity(city):
+			// flights abroad aren't missing from the mapping, they're international
+			resolved[key] = true
+		case !ok:
+			entry := &UnmappedCity{Name: strings.TrimSpace(city), Flights: 1}
+			if isSource {
+				entry.AsSource = 1
+			} else {
+				entry.AsDestination = 1
+			}
+			unmapped[key] = entry
+			return false
+		case match.Method == CityMatchFuzzy:
+			fuzzy[key] = &FuzzyMatchedCity{Name: strings.TrimSpace(city), Flights: 1, Match: match}
+		default:
+			resolved[key] = true
+		}
+		return true
+	}
+
+	for _, flight := range flights {
+		sameCity := strings.EqualFold(strings.TrimSpace(flight.Source), strings.TrimSpace(flight.Destination))
+		sourceOk := check(flight.Source, true, true)
+		destOk := check(flight.Destination, false, !sameCity)
+		if !sourceOk || !destOk {
+			report.AffectedFlights++
+		}
+	}
+
+	for _, entry := range unmapped {
+		entry.Suggestions = []CitySuggestion{}
+		for _, suggestion := range csm.SuggestCities(entry.Name, 3) {
+			if suggestion.Confidence >= minSuggestionConfidence {
+				entry.Suggestions = append(entry.Suggestions, suggestion)
+			}
+		}
+		report.Unmapped = append(report.Unmapped, *entry)
+	}
+	sort.Slice(report.Unmapped, func(i, j int) bool {
+		if report.Unmapped[i].Flights != report.Unmapped[j].Flights {
+			return report.Unmapped[i].Flights > report.Unmapped[j].Flights
+		}
+		return report.Unmapped[i].Name < report.Unmapped[j].Name
+	})
+
+	for _, entry := range fuzzy {
+		report.FuzzyMatched = append(report.FuzzyMatched, *entry)
+	}
+	sort.Slice(report.FuzzyMatched, func(i, j int) bool {
+		if report.FuzzyMatched[i].Flights != report.FuzzyMatched[j].Flights {
+			return report.FuzzyMatched[i].Flights > report.FuzzyMatched[j].Flights
+		}
+		return report.FuzzyMatched[i].Name < report.FuzzyMatched[j].Name
+	})
+
+	return report
+}